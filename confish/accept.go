@@ -0,0 +1,29 @@
+package confish
+
+import "context"
+
+// defaultAccept is the media type config requests ask for unless overridden
+const defaultAccept = "application/json"
+
+type acceptKey struct{}
+
+// ContextWithAccept returns a context that makes config requests made with it
+// send accept as the Accept header, e.g. "application/x-yaml". The response
+// is decoded according to its Content-Type, so register a decoder for the
+// media type in ConfishConfig.Decoders.
+func ContextWithAccept(ctx context.Context, accept string) context.Context {
+	return context.WithValue(ctx, acceptKey{}, accept)
+}
+
+// acceptFor returns the Accept header for a config request made with ctx:
+// the value from ContextWithAccept, else the one in ConfishConfig.Headers,
+// else application/json
+func (c *Client) acceptFor(ctx context.Context) string {
+	if accept, _ := ctx.Value(acceptKey{}).(string); accept != "" {
+		return accept
+	}
+	if accept := c.cfg.Headers.Get("Accept"); accept != "" {
+		return accept
+	}
+	return defaultAccept
+}
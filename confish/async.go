@@ -0,0 +1,137 @@
+package confish
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// defaultAsyncQueueSize is used when ConfishConfig.AsyncQueueSize is not set
+const defaultAsyncQueueSize = 1000
+
+// ErrLogQueueFull is passed to the ErrorHandler when LogAsync drops a message
+// because the queue is full, and returned by StreamLogger.Write when its
+// buffer is full
+var ErrLogQueueFull = errors.New("confish: log queue full")
+
+// asyncLogger sends queued log payloads from a single worker goroutine
+type asyncLogger struct {
+	client *Client
+	queue  chan LogPayload
+	done   chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func newAsyncLogger(c *Client) *asyncLogger {
+	size := c.cfg.AsyncQueueSize
+	if size <= 0 {
+		size = defaultAsyncQueueSize
+	}
+
+	a := &asyncLogger{
+		client: c,
+		queue:  make(chan LogPayload, size),
+		done:   make(chan struct{}),
+	}
+	go a.run()
+
+	return a
+}
+
+func (a *asyncLogger) run() {
+	defer close(a.done)
+
+	for payload := range a.queue {
+		if err := a.client.sendPayload(context.Background(), payload); err != nil {
+			a.client.handleError(err)
+		}
+	}
+}
+
+// enqueue adds payload to the queue without blocking
+func (a *asyncLogger) enqueue(payload LogPayload) error {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+
+	if a.closed {
+		return ErrClientClosed
+	}
+
+	select {
+	case a.queue <- payload:
+		return nil
+	default:
+		return ErrLogQueueFull
+	}
+}
+
+// shutdown stops accepting payloads and waits until the queue is drained or ctx is done
+func (a *asyncLogger) shutdown(ctx context.Context) error {
+	a.mu.Lock()
+	if !a.closed {
+		a.closed = true
+		close(a.queue)
+	}
+	a.mu.Unlock()
+
+	select {
+	case <-a.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// LogAsync queues a log message and returns immediately. A single background
+// goroutine sends queued messages in order and passes delivery errors to the
+// ErrorHandler. When the queue (AsyncQueueSize) is full the new message is
+// dropped and ErrLogQueueFull is passed to the ErrorHandler. Invalid levels
+// are reported there as ErrInvalidLogLevel.
+func (c *Client) LogAsync(level LogLevel, message string) {
+	if !level.Valid() {
+		c.handleError(fmt.Errorf("%w: %q", ErrInvalidLogLevel, level))
+		return
+	}
+
+	if !c.levelEnabled(level) || !c.sampled(level) {
+		return
+	}
+
+	c.asyncOnce.Do(func() {
+		c.async = newAsyncLogger(c)
+	})
+
+	payload, err := c.newPayload(context.Background(), level, message, nil)
+	if err != nil {
+		c.handleError(err)
+		return
+	}
+
+	if err := c.async.enqueue(payload); err != nil {
+		c.handleError(err)
+	}
+}
+
+// Shutdown stops accepting LogAsync messages and waits until the queued ones
+// have been sent and any batched entries flushed, or until ctx is done. Later
+// LogAsync calls are dropped with ErrClientClosed.
+func (c *Client) Shutdown(ctx context.Context) error {
+	if err := c.stopAsync(ctx); err != nil {
+		return err
+	}
+
+	return c.Flush()
+}
+
+// stopAsync shuts the LogAsync worker down, making sure later LogAsync calls
+// are rejected even if it was never started
+func (c *Client) stopAsync(ctx context.Context) error {
+	c.asyncOnce.Do(func() {
+		c.async = newAsyncLogger(c)
+	})
+
+	return c.async.shutdown(ctx)
+}
@@ -0,0 +1,109 @@
+package confish_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bravilogy/confish-go/confish"
+)
+
+// errorRecorder collects the errors passed to an ErrorHandler
+type errorRecorder struct {
+	mu   sync.Mutex
+	errs []error
+}
+
+func (r *errorRecorder) handle(err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errs = append(r.errs, err)
+}
+
+func (r *errorRecorder) all() []error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]error(nil), r.errs...)
+}
+
+func TestLogAsyncDropsWhenQueueFull(t *testing.T) {
+	received := make(chan string, 16)
+	release := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload confish.LogPayload
+		json.NewDecoder(r.Body).Decode(&payload)
+		received <- payload.Message
+		<-release
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	var recorder errorRecorder
+	client, err := confish.NewClientWithOptions(server.URL, "app", "secret",
+		func(cfg *confish.ConfishConfig) { cfg.AsyncQueueSize = 2 },
+		confish.WithErrorHandler(recorder.handle),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.LogAsync(confish.LogLevelInfo, "1")
+	select {
+	case <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("first message was not sent")
+	}
+
+	// The worker is busy with the first message, so two more fill the queue
+	for _, message := range []string{"2", "3", "4"} {
+		client.LogAsync(confish.LogLevelInfo, message)
+	}
+	if errs := recorder.all(); len(errs) != 1 || !errors.Is(errs[0], confish.ErrLogQueueFull) {
+		t.Fatalf("reported errors = %v, want one ErrLogQueueFull", errs)
+	}
+
+	close(release)
+	if err := client.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() = %v", err)
+	}
+	close(received)
+
+	var got []string
+	for message := range received {
+		got = append(got, message)
+	}
+	if len(got) != 2 || got[0] != "2" || got[1] != "3" {
+		t.Errorf("messages sent after the first = %q, want 2 and 3", got)
+	}
+}
+
+func TestLogAsyncReportsDeliveryErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	var recorder errorRecorder
+	client, err := confish.NewClientWithOptions(server.URL, "app", "secret",
+		confish.WithRetries(0, 0),
+		confish.WithErrorHandler(recorder.handle),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	client.LogAsync(confish.LogLevelError, "boom")
+	if err := client.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown() = %v", err)
+	}
+
+	var httpErr *confish.HTTPError
+	if errs := recorder.all(); len(errs) != 1 || !errors.As(errs[0], &httpErr) || httpErr.StatusCode != http.StatusInternalServerError {
+		t.Errorf("reported errors = %v, want the 500", errs)
+	}
+}
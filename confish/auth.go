@@ -0,0 +1,71 @@
+package confish
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+)
+
+// Authorizer authenticates a request to the Confish API before it is sent,
+// typically by setting headers. Apply is called for every attempt and must be
+// safe for concurrent use.
+type Authorizer interface {
+	Apply(req *http.Request) error
+}
+
+// appSecretAuthorizer is the default Authorizer, sending the client's current
+// app ID and secret in the App-ID and App-Secret headers
+type appSecretAuthorizer struct {
+	client *Client
+}
+
+func (a appSecretAuthorizer) Apply(req *http.Request) error {
+	appID, appSecret := a.client.credentials()
+	req.Header.Set("App-ID", appID)
+	req.Header.Set("App-Secret", appSecret)
+	return nil
+}
+
+// ErrNoToken is returned when a BearerTokenAuthorizer has no token to send
+var ErrNoToken = errors.New("confish: no bearer token")
+
+// BearerTokenAuthorizer sends a bearer token in the Authorization header.
+// Call SetToken to replace the token, e.g. after refreshing it. It is safe
+// for concurrent use.
+type BearerTokenAuthorizer struct {
+	mu    sync.RWMutex
+	token string
+}
+
+// NewBearerTokenAuthorizer returns an authorizer sending token
+func NewBearerTokenAuthorizer(token string) *BearerTokenAuthorizer {
+	return &BearerTokenAuthorizer{token: token}
+}
+
+// SetToken replaces the token sent with requests started from now on
+func (a *BearerTokenAuthorizer) SetToken(token string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.token = token
+}
+
+// Apply sets the Authorization header, failing with ErrNoToken when the
+// token is empty
+func (a *BearerTokenAuthorizer) Apply(req *http.Request) error {
+	a.mu.RLock()
+	token := a.token
+	a.mu.RUnlock()
+
+	if token == "" {
+		return ErrNoToken
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// String hides the token
+func (a *BearerTokenAuthorizer) String() string {
+	return "BearerTokenAuthorizer{****}"
+}
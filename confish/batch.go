@@ -0,0 +1,267 @@
+package confish
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	// defaultMaxBatchSize is used when BatchConfig.MaxBatchSize is not set
+	defaultMaxBatchSize = 100
+	// defaultFlushInterval is used when BatchConfig.FlushInterval is not set
+	defaultFlushInterval = 5 * time.Second
+)
+
+// ErrClientClosed is returned by log calls made after the client was closed
+var ErrClientClosed = errors.New("confish: client closed")
+
+// BatchConfig configures batched logging. Buffered payloads are sent as a JSON
+// array to the /a/{appID}/log/batch endpoint when MaxBatchSize entries have
+// accumulated or FlushInterval has passed, whichever comes first. Delivery
+// errors are passed to ConfishConfig.ErrorHandler.
+type BatchConfig struct {
+	// MaxBatchSize is the number of entries that triggers a flush. Defaults to 100.
+	MaxBatchSize int
+	// FlushInterval is the longest an entry waits before being sent. Defaults to 5s.
+	FlushInterval time.Duration
+	// QueueSize bounds the number of entries waiting to be batched. Defaults
+	// to MaxBatchSize.
+	QueueSize int
+	// DropWhenFull makes Log silently discard entries while the queue is full
+	// instead of blocking until there is room or the context is done
+	DropWhenFull bool
+}
+
+// logBatcher buffers log payloads and sends them in batches from a single goroutine
+type logBatcher struct {
+	client   *Client
+	cfg      BatchConfig
+	queue    chan LogPayload
+	flushReq chan chan error
+	stop     chan struct{}
+	stopped  chan struct{}
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+func newLogBatcher(c *Client, cfg BatchConfig) *logBatcher {
+	if cfg.MaxBatchSize <= 0 {
+		cfg.MaxBatchSize = defaultMaxBatchSize
+	}
+	if cfg.FlushInterval <= 0 {
+		cfg.FlushInterval = defaultFlushInterval
+	}
+	if cfg.QueueSize <= 0 {
+		cfg.QueueSize = cfg.MaxBatchSize
+	}
+
+	b := &logBatcher{
+		client:   c,
+		cfg:      cfg,
+		queue:    make(chan LogPayload, cfg.QueueSize),
+		flushReq: make(chan chan error),
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+	}
+	go b.run()
+
+	return b
+}
+
+// enqueue adds payload to the queue, blocking or dropping when it is full
+func (b *logBatcher) enqueue(ctx context.Context, payload LogPayload) error {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if b.closed {
+		return ErrClientClosed
+	}
+
+	if b.cfg.DropWhenFull {
+		select {
+		case b.queue <- payload:
+		default:
+		}
+		return nil
+	}
+
+	select {
+	case b.queue <- payload:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (b *logBatcher) run() {
+	defer close(b.stopped)
+
+	ticker := b.client.clock.NewTicker(b.cfg.FlushInterval)
+	defer ticker.Stop()
+
+	buf := make([]LogPayload, 0, b.cfg.MaxBatchSize)
+	send := func() error {
+		if len(buf) == 0 {
+			return nil
+		}
+		err := b.client.sendLogBatch(context.Background(), buf)
+		buf = make([]LogPayload, 0, b.cfg.MaxBatchSize)
+		return err
+	}
+
+	for {
+		select {
+		case payload := <-b.queue:
+			buf = append(buf, payload)
+			if len(buf) >= b.cfg.MaxBatchSize {
+				b.report(send())
+			}
+		case <-ticker.C():
+			b.report(send())
+		case reply := <-b.flushReq:
+			buf = b.drain(buf)
+			reply <- send()
+		case <-b.stop:
+			buf = b.drain(buf)
+			b.report(send())
+			return
+		}
+	}
+}
+
+// drain moves everything currently queued into buf, sending full batches on the way
+func (b *logBatcher) drain(buf []LogPayload) []LogPayload {
+	for {
+		select {
+		case payload := <-b.queue:
+			buf = append(buf, payload)
+			if len(buf) >= b.cfg.MaxBatchSize {
+				b.report(b.client.sendLogBatch(context.Background(), buf))
+				buf = make([]LogPayload, 0, b.cfg.MaxBatchSize)
+			}
+		default:
+			return buf
+		}
+	}
+}
+
+func (b *logBatcher) report(err error) {
+	if err != nil {
+		b.client.handleError(err)
+	}
+}
+
+// flush sends everything buffered so far and waits for the delivery
+func (b *logBatcher) flush() error {
+	reply := make(chan error, 1)
+	select {
+	case b.flushReq <- reply:
+		return <-reply
+	case <-b.stopped:
+		return nil
+	}
+}
+
+// close rejects further entries and waits until the buffered ones are sent or ctx is done
+func (b *logBatcher) close(ctx context.Context) error {
+	b.mu.Lock()
+	if !b.closed {
+		b.closed = true
+		close(b.stop)
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-b.stopped:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// PartialLogError is returned when the batch logging endpoint accepted only
+// some of the entries sent to it
+type PartialLogError struct {
+	Accepted int
+	Total    int
+}
+
+func (e *PartialLogError) Error() string {
+	return fmt.Sprintf("confish accepted %d of %d log entries", e.Accepted, e.Total)
+}
+
+// batchLogResponse is the optional body of a batch logging response
+type batchLogResponse struct {
+	Accepted *int `json:"accepted"`
+}
+
+// sendLogBatch posts payloads as a JSON array to the batch logging endpoint.
+// If the response reports fewer accepted entries than were sent, a
+// *PartialLogError is returned.
+func (c *Client) sendLogBatch(ctx context.Context, payloads []LogPayload) error {
+	var result batchLogResponse
+	if err := c.postLog(ctx, OpLogBatch, c.logEndpoint("batch"), payloads, &result); err != nil {
+		return err
+	}
+
+	if result.Accepted != nil && *result.Accepted < len(payloads) {
+		return &PartialLogError{Accepted: *result.Accepted, Total: len(payloads)}
+	}
+
+	return nil
+}
+
+// LogMany sends several log entries in a single request to the batch logging
+// endpoint. Every level is checked before anything is sent, default fields
+// are merged in and entries below the minimum level are dropped. If the
+// server rejects some entries, a *PartialLogError tells how many it accepted.
+func (c *Client) LogMany(payloads []LogPayload) error {
+	return c.LogManyContext(context.Background(), payloads)
+}
+
+// LogManyContext is like LogMany but aborts the request when ctx is cancelled or its deadline passes
+func (c *Client) LogManyContext(ctx context.Context, payloads []LogPayload) error {
+	if c.closed.Load() {
+		return ErrClientClosed
+	}
+
+	batch := make([]LogPayload, 0, len(payloads))
+	for i, p := range payloads {
+		if !p.Level.Valid() {
+			return fmt.Errorf("%w: %q at index %d", ErrInvalidLogLevel, p.Level, i)
+		}
+		if !c.levelEnabled(p.Level) || !c.sampled(p.Level) {
+			continue
+		}
+
+		payload, err := c.newPayload(ctx, p.Level, p.Message, p.Fields)
+		if err != nil {
+			return fmt.Errorf("log entry at index %d: %w", i, err)
+		}
+		batch = append(batch, payload)
+	}
+
+	if len(batch) == 0 {
+		return nil
+	}
+
+	return c.sendLogBatch(ctx, batch)
+}
+
+// Flush sends any buffered log entries, including a pending deduplication
+// summary, immediately and returns the delivery error, if any. It is a no-op
+// when neither batching nor deduplication is enabled.
+func (c *Client) Flush() error {
+	if err := c.dedup.flush(context.Background()); err != nil {
+		return err
+	}
+
+	if c.batcher == nil {
+		return nil
+	}
+	return c.batcher.flush()
+}
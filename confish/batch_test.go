@@ -0,0 +1,144 @@
+package confish
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// batchServer sends the size of every batch it receives on the returned
+// channel and answers with accepted, or with every entry accepted when it is
+// negative
+func batchServer(t *testing.T, accepted int) (*httptest.Server, <-chan int) {
+	t.Helper()
+
+	batches := make(chan int, 16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payloads []LogPayload
+		if err := json.NewDecoder(r.Body).Decode(&payloads); err != nil {
+			t.Errorf("batch body: %v", err)
+		}
+		batches <- len(payloads)
+
+		n := accepted
+		if n < 0 {
+			n = len(payloads)
+		}
+		json.NewEncoder(w).Encode(map[string]int{"accepted": n})
+	}))
+	t.Cleanup(server.Close)
+	return server, batches
+}
+
+// nextBatch returns the size of the next batch the server receives
+func nextBatch(t *testing.T, batches <-chan int) int {
+	t.Helper()
+	select {
+	case n := <-batches:
+		return n
+	case <-time.After(5 * time.Second):
+		t.Fatal("no batch was sent")
+		return 0
+	}
+}
+
+// waitQueued waits until the batcher has taken every queued entry
+func waitQueued(t *testing.T, b *logBatcher) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(b.queue) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatal("batcher did not take the queued entries")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestBatchFlushesAtMaxBatchSize(t *testing.T) {
+	server, batches := batchServer(t, -1)
+	client, err := NewClientWithOptions(server.URL, "app", "secret",
+		WithBatching(BatchConfig{MaxBatchSize: 3, FlushInterval: time.Hour, QueueSize: 10}),
+		withClock(newFakeClock()),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close(context.Background())
+
+	for i := 0; i < 4; i++ {
+		if err := client.Info("entry"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n := nextBatch(t, batches); n != 3 {
+		t.Errorf("first batch has %d entries, want 3", n)
+	}
+	select {
+	case n := <-batches:
+		t.Errorf("batch of %d entries sent before the interval or batch size was reached", n)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestBatchFlushesAfterInterval(t *testing.T) {
+	server, batches := batchServer(t, -1)
+	clk := newFakeClock()
+	client, err := NewClientWithOptions(server.URL, "app", "secret",
+		WithBatching(BatchConfig{MaxBatchSize: 100, FlushInterval: 5 * time.Second}),
+		withClock(clk),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close(context.Background())
+
+	if d := clk.waitTicker(t); d != 5*time.Second {
+		t.Fatalf("flush interval = %v, want 5s", d)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := client.Info("entry"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	waitQueued(t, client.batcher)
+
+	clk.Advance(4 * time.Second)
+	select {
+	case n := <-batches:
+		t.Fatalf("batch of %d entries sent before the interval elapsed", n)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	clk.Advance(time.Second)
+	if n := nextBatch(t, batches); n != 2 {
+		t.Errorf("batch has %d entries, want 2", n)
+	}
+}
+
+func TestLogManyPartialLogError(t *testing.T) {
+	server, _ := batchServer(t, 1)
+	client, err := NewClientWithOptions(server.URL, "app", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = client.LogMany([]LogPayload{
+		{Level: LogLevelInfo, Message: "one"},
+		{Level: LogLevelInfo, Message: "two"},
+		{Level: LogLevelInfo, Message: "three"},
+	})
+
+	var partial *PartialLogError
+	if !errors.As(err, &partial) {
+		t.Fatalf("LogMany() error = %v, want a *PartialLogError", err)
+	}
+	if partial.Accepted != 1 || partial.Total != 3 {
+		t.Errorf("PartialLogError = %+v, want 1 of 3 accepted", partial)
+	}
+}
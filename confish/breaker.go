@@ -0,0 +1,201 @@
+package confish
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without contacting the API while the circuit
+// breaker is open
+var ErrCircuitOpen = errors.New("confish: circuit breaker is open")
+
+const (
+	defaultFailureThreshold = 5
+	defaultCircuitCooldown  = 30 * time.Second
+)
+
+// CircuitBreakerConfig configures the circuit breaker. Connection errors,
+// timeouts and 5xx responses count as failures; retries of one call count
+// once. When FailureThreshold calls in a row fail, the circuit opens and all
+// requests fail fast with ErrCircuitOpen. After Cooldown a single probe
+// request is let through: if it succeeds the circuit closes, otherwise it
+// opens for another Cooldown.
+type CircuitBreakerConfig struct {
+	// FailureThreshold is the number of consecutive failures that opens the
+	// circuit. Defaults to 5.
+	FailureThreshold int
+
+	// Cooldown is how long the circuit stays open before probing. Defaults
+	// to 30s.
+	Cooldown time.Duration
+}
+
+// CircuitState is the state of the client's circuit breaker
+type CircuitState int
+
+const (
+	// CircuitClosed lets requests through
+	CircuitClosed CircuitState = iota
+	// CircuitOpen fails requests fast with ErrCircuitOpen
+	CircuitOpen
+	// CircuitHalfOpen lets a single probe request through
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	switch s {
+	case CircuitClosed:
+		return "closed"
+	case CircuitOpen:
+		return "open"
+	case CircuitHalfOpen:
+		return "half-open"
+	default:
+		return "unknown"
+	}
+}
+
+// breakerOutcome is how a finished request affects the breaker
+type breakerOutcome int
+
+const (
+	outcomeSuccess breakerOutcome = iota
+	outcomeFailure
+	// outcomeIgnored is for requests cancelled by the caller, which say
+	// nothing about the API's health
+	outcomeIgnored
+)
+
+// circuitBreaker implements CircuitBreakerConfig. A nil breaker lets every
+// request through.
+type circuitBreaker struct {
+	mu        sync.Mutex
+	threshold int
+	cooldown  time.Duration
+	state     CircuitState
+	failures  int
+	openedAt  time.Time
+	probing   bool
+	clock     clock
+}
+
+func newCircuitBreaker(cfg *CircuitBreakerConfig, clk clock) *circuitBreaker {
+	if cfg == nil {
+		return nil
+	}
+
+	b := &circuitBreaker{threshold: cfg.FailureThreshold, cooldown: cfg.Cooldown, clock: clk}
+	if b.threshold <= 0 {
+		b.threshold = defaultFailureThreshold
+	}
+	if b.cooldown <= 0 {
+		b.cooldown = defaultCircuitCooldown
+	}
+
+	return b
+}
+
+// allow reports whether a request may be sent, returning ErrCircuitOpen if not
+func (b *circuitBreaker) allow() error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case CircuitOpen:
+		if b.clock.Now().Sub(b.openedAt) < b.cooldown {
+			return ErrCircuitOpen
+		}
+		b.state = CircuitHalfOpen
+	case CircuitHalfOpen:
+		if b.probing {
+			return ErrCircuitOpen
+		}
+	default:
+		return nil
+	}
+
+	b.probing = true
+	return nil
+}
+
+// record updates the breaker with the outcome of a request allow admitted
+func (b *circuitBreaker) record(outcome breakerOutcome) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitHalfOpen {
+		b.probing = false
+		switch outcome {
+		case outcomeSuccess:
+			b.state = CircuitClosed
+			b.failures = 0
+		case outcomeFailure:
+			b.open()
+		}
+		return
+	}
+
+	if b.state != CircuitClosed {
+		return
+	}
+
+	switch outcome {
+	case outcomeSuccess:
+		b.failures = 0
+	case outcomeFailure:
+		b.failures++
+		if b.failures >= b.threshold {
+			b.open()
+		}
+	}
+}
+
+func (b *circuitBreaker) open() {
+	b.state = CircuitOpen
+	b.openedAt = b.clock.Now()
+	b.failures = 0
+}
+
+// currentState returns the state, reporting an open circuit whose cooldown
+// has passed as half-open
+func (b *circuitBreaker) currentState() CircuitState {
+	if b == nil {
+		return CircuitClosed
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == CircuitOpen && b.clock.Now().Sub(b.openedAt) >= b.cooldown {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// outcomeOf classifies the result of a request for the breaker
+func outcomeOf(req *http.Request, resp *http.Response, err error) breakerOutcome {
+	switch {
+	case err != nil && req.Context().Err() != nil:
+		return outcomeIgnored
+	case err != nil, resp.StatusCode >= 500:
+		return outcomeFailure
+	default:
+		return outcomeSuccess
+	}
+}
+
+// CircuitState returns the state of the circuit breaker, for health checks.
+// It is always CircuitClosed when no breaker is configured.
+func (c *Client) CircuitState() CircuitState {
+	return c.breaker.currentState()
+}
@@ -0,0 +1,51 @@
+package confish
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// BuildConfigRequest returns the authenticated request GetConfig would send
+// for configID, without sending it. The caller executes it and is responsible
+// for checking the status, decompressing and decoding the body, and closing
+// it. The client's Timeout and retries are not applied.
+func (c *Client) BuildConfigRequest(ctx context.Context, configID string) (*http.Request, error) {
+	req, err := c.newConfigRequest(ctx, configID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	return req, nil
+}
+
+// BuildLogRequest returns the authenticated request Log would send for a
+// message, with default and ctx fields and the message size limit applied, without
+// sending it. MinLevel and sampling are not checked. The caller executes it
+// and is responsible for checking the status and closing the body. The
+// client's Timeout and retries are not applied.
+func (c *Client) BuildLogRequest(ctx context.Context, level LogLevel, message string) (*http.Request, error) {
+	if !level.Valid() {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidLogLevel, level)
+	}
+
+	payload, err := c.newPayload(ctx, level, message, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	jsonPayload, err := c.marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal log payload: %w", err)
+	}
+
+	req, err := c.newRequest(ctx, "POST", c.logEndpoint(), jsonPayload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create log request: %w", err)
+	}
+	if err := c.setIdempotencyKey(req); err != nil {
+		return nil, fmt.Errorf("failed to create log request: %w", err)
+	}
+
+	return req, nil
+}
@@ -0,0 +1,206 @@
+package confish
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// cacheEntry holds a raw config body and when it stops being served
+type cacheEntry struct {
+	body        []byte
+	contentType string
+	expires     time.Time
+}
+
+// configCache is a concurrency-safe store of raw config bodies keyed by config ID
+type configCache struct {
+	mu      sync.RWMutex
+	entries map[string]cacheEntry
+}
+
+func newConfigCache() *configCache {
+	return &configCache{entries: make(map[string]cacheEntry)}
+}
+
+// get returns the cached entry for configID if present and not expired
+func (cc *configCache) get(configID string, now time.Time) (cacheEntry, bool) {
+	cc.mu.RLock()
+	defer cc.mu.RUnlock()
+
+	entry, ok := cc.entries[configID]
+	if !ok || !now.Before(entry.expires) {
+		return cacheEntry{}, false
+	}
+
+	return entry, true
+}
+
+func (cc *configCache) set(configID string, entry cacheEntry) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	cc.entries[configID] = entry
+}
+
+func (cc *configCache) delete(configID string) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	delete(cc.entries, configID)
+}
+
+// etagEntry is the last body the server returned for a config along with its ETag
+type etagEntry struct {
+	etag        string
+	contentType string
+	version     string
+	body        []byte
+}
+
+// etagStore remembers ETags per config ID for conditional requests
+type etagStore struct {
+	mu      sync.Mutex
+	entries map[string]etagEntry
+}
+
+func newETagStore() *etagStore {
+	return &etagStore{entries: make(map[string]etagEntry)}
+}
+
+func (es *etagStore) get(configID string) (etagEntry, bool) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	entry, ok := es.entries[configID]
+	return entry, ok
+}
+
+func (es *etagStore) set(configID string, entry etagEntry) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	es.entries[configID] = entry
+}
+
+func (es *etagStore) delete(configID string) {
+	es.mu.Lock()
+	defer es.mu.Unlock()
+
+	delete(es.entries, configID)
+}
+
+// cacheTTL returns how long a config served with header may be cached, as
+// allowed by its Cache-Control max-age or, failing that, its Expires header,
+// and never longer than ceiling. no-store and no-cache disable caching.
+// Without either header the config is cached for ceiling.
+func cacheTTL(header http.Header, now time.Time, ceiling time.Duration) time.Duration {
+	ttl, ok := maxAge(header)
+	if !ok {
+		ttl, ok = expiresIn(header, now)
+	}
+	if !ok || ttl > ceiling {
+		return ceiling
+	}
+	return ttl
+}
+
+// maxAge returns the freshness lifetime given by the Cache-Control header,
+// less the Age the response already has
+func maxAge(header http.Header) (time.Duration, bool) {
+	var ttl time.Duration
+	found := false
+	for _, value := range header.Values("Cache-Control") {
+		for _, directive := range strings.Split(value, ",") {
+			name, arg, _ := strings.Cut(strings.TrimSpace(directive), "=")
+			switch strings.ToLower(name) {
+			case "no-store", "no-cache":
+				return 0, true
+			case "max-age":
+				seconds, err := strconv.Atoi(strings.Trim(arg, `"`))
+				if err != nil {
+					continue
+				}
+				ttl, found = time.Duration(seconds)*time.Second, true
+			}
+		}
+	}
+	if !found {
+		return 0, false
+	}
+
+	if age, err := strconv.Atoi(header.Get("Age")); err == nil && age > 0 {
+		ttl -= time.Duration(age) * time.Second
+	}
+	return max(ttl, 0), true
+}
+
+// expiresIn returns the time left until the Expires header, measured from the
+// response's Date when present. An invalid Expires means already expired.
+func expiresIn(header http.Header, now time.Time) (time.Duration, bool) {
+	value := header.Get("Expires")
+	if value == "" {
+		return 0, false
+	}
+
+	expires, err := http.ParseTime(value)
+	if err != nil {
+		return 0, true
+	}
+	if date, err := http.ParseTime(header.Get("Date")); err == nil {
+		now = date
+	}
+	return max(expires.Sub(now), 0), true
+}
+
+// GetConfigCached is like GetConfig but serves the config from memory until
+// CacheTTL has passed since it was fetched. A shorter lifetime sent by the
+// server in Cache-Control max-age or Expires takes precedence, and
+// Cache-Control no-store or no-cache keeps the config out of the cache.
+// Concurrent calls missing the cache for the same config share one request
+// unless DisableSingleflight is set. With a zero CacheTTL it always fetches
+// from the API.
+func (c *Client) GetConfigCached(configID string, result interface{}) error {
+	return c.GetConfigCachedContext(context.Background(), configID, result)
+}
+
+// GetConfigCachedContext is like GetConfigCached but aborts the request when ctx is cancelled or its deadline passes
+func (c *Client) GetConfigCachedContext(ctx context.Context, configID string, result interface{}) error {
+	if err := checkTarget(result); err != nil {
+		return err
+	}
+
+	if c.cfg.CacheTTL <= 0 {
+		return c.GetConfigContext(ctx, configID, result)
+	}
+
+	if entry, ok := c.cache.get(configID, c.clock.Now()); ok {
+		return c.decodeConfig(configID, entry.body, entry.contentType, result)
+	}
+
+	body, meta, err := c.fetchConfigShared(ctx, configID, func(body []byte, meta ConfigMeta) {
+		now := c.clock.Now()
+		if ttl := cacheTTL(meta.Header, now, c.cfg.CacheTTL); ttl > 0 {
+			c.cache.set(configID, cacheEntry{
+				body:        body,
+				contentType: meta.ContentType,
+				expires:     now.Add(ttl),
+			})
+		}
+	})
+	if err != nil {
+		return err
+	}
+
+	return c.decodeConfig(configID, body, meta.ContentType, result)
+}
+
+// InvalidateConfig drops configID from the cache so the next GetConfigCached
+// call fetches it from the API, e.g. after a webhook reports a change
+func (c *Client) InvalidateConfig(configID string) {
+	c.flights.forget(configID)
+	c.cache.delete(configID)
+}
@@ -0,0 +1,42 @@
+package confish
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCacheTTL(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	date := func(d time.Duration) string { return now.Add(d).Format(http.TimeFormat) }
+	ceiling := time.Hour
+
+	tests := []struct {
+		name   string
+		header http.Header
+		want   time.Duration
+	}{
+		{"no headers", http.Header{}, ceiling},
+		{"max-age", http.Header{"Cache-Control": {"public, max-age=60"}}, time.Minute},
+		{"quoted max-age", http.Header{"Cache-Control": {`max-age="60"`}}, time.Minute},
+		{"max-age above the ceiling", http.Header{"Cache-Control": {"max-age=86400"}}, ceiling},
+		{"max-age less Age", http.Header{"Cache-Control": {"max-age=60"}, "Age": {"45"}}, 15 * time.Second},
+		{"Age past max-age", http.Header{"Cache-Control": {"max-age=60"}, "Age": {"90"}}, 0},
+		{"malformed max-age", http.Header{"Cache-Control": {"max-age=soon"}}, ceiling},
+		{"no-store", http.Header{"Cache-Control": {"no-store"}}, 0},
+		{"no-cache", http.Header{"Cache-Control": {"max-age=60, No-Cache"}}, 0},
+		{"directives over several headers", http.Header{"Cache-Control": {"max-age=60", "no-store"}}, 0},
+		{"Expires in the future", http.Header{"Expires": {date(10 * time.Minute)}}, 10 * time.Minute},
+		{"Expires in the past", http.Header{"Expires": {date(-time.Minute)}}, 0},
+		{"malformed Expires", http.Header{"Expires": {"0"}}, 0},
+		{"Expires measured from Date", http.Header{"Expires": {date(10 * time.Minute)}, "Date": {date(5 * time.Minute)}}, 5 * time.Minute},
+		{"max-age over Expires", http.Header{"Cache-Control": {"max-age=30"}, "Expires": {date(10 * time.Minute)}}, 30 * time.Second},
+		{"max-age over a past Expires", http.Header{"Cache-Control": {"max-age=30"}, "Expires": {date(-time.Minute)}}, 30 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := cacheTTL(tt.header, now, ceiling); got != tt.want {
+			t.Errorf("cacheTTL() with %s = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
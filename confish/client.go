@@ -2,11 +2,19 @@ package confish
 
 import (
 	"bytes"
+	"context"
+	"crypto/tls"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 type ConfishConfig struct {
@@ -14,11 +22,284 @@ type ConfishConfig struct {
 	AppID       string
 	AppSecret   string
 	WebhookPath string
+
+	// ConfigPathTemplate and LogPathTemplate are the API paths, relative to
+	// URL, of a config and of the app's log endpoint, for Confish versions
+	// with different routing, e.g. "/v2/c/{id}". {id} is replaced with the
+	// config ID and {appID} with the AppID. They default to
+	// DefaultConfigPathTemplate and DefaultLogPathTemplate. Named streams,
+	// batches and log streaming use sub-paths of the log path; ListConfigs
+	// always uses /a/{appID}/configs.
+	ConfigPathTemplate string
+	LogPathTemplate    string
+
+	// AppSecretFile is read for the app secret instead of AppSecret, e.g. a
+	// mounted Kubernetes or Docker secret. Surrounding whitespace is trimmed.
+	// Call ReloadAppSecret after the file changes to pick up a rotated secret.
+	AppSecretFile string
+
+	// Authorizer, when set, authenticates every request instead of the
+	// App-ID and App-Secret headers, e.g. a BearerTokenAuthorizer. AppSecret
+	// is then optional; it is only used to verify webhook signatures. The
+	// same holds for TokenSource.
+	Authorizer Authorizer
+
+	// TokenSource, when set, is called for a bearer token to authenticate
+	// requests with, like an Authorizer. The token is reused until shortly
+	// before it expires, concurrent requests share a single refresh, and a
+	// request rejected with 401 is retried once with a new token. It cannot
+	// be combined with Authorizer.
+	TokenSource TokenSource
+
+	// HTTPClient is used for all requests when set. If nil, a shared
+	// package-level client is used instead of http.DefaultClient.
+	HTTPClient *http.Client
+
+	// MaxIdleConnsPerHost and IdleConnTimeout tune connection reuse of the
+	// built-in transport. They default to 16 and 90s and are ignored when
+	// HTTPClient is set.
+	MaxIdleConnsPerHost int
+	IdleConnTimeout     time.Duration
+
+	// ProxyURL, when set, routes every request through this proxy, e.g.
+	// "http://proxy.internal:3128", instead of the one from the HTTP_PROXY
+	// and HTTPS_PROXY environment variables. Credentials may be given in the
+	// URL's user info.
+	ProxyURL string
+
+	// TLSConfig is used for connections to the API, e.g. to trust a private
+	// CA. ClientCertFile and ClientKeyFile name a PEM certificate and key
+	// presented for mutual TLS; they are added to TLSConfig's certificates.
+	// When HTTPClient is also set, the TLS settings are applied to a copy of
+	// its transport, which must then be an *http.Transport.
+	TLSConfig      *tls.Config
+	ClientCertFile string
+	ClientKeyFile  string
+
+	// Timeout bounds each request when greater than zero. If the caller's
+	// context has an earlier deadline, that deadline wins. The zero value
+	// means no timeout.
+	Timeout time.Duration
+
+	// MaxRetries is the number of times a failed request is retried. Config
+	// fetches are retried on connection errors and 502/503/504 responses; log
+	// requests only on connection errors. Rate-limited config fetches (429)
+	// are retried after the server's Retry-After delay. Zero disables retries.
+	MaxRetries int
+
+	// RetryBaseDelay is the initial backoff between retries, doubled on each
+	// attempt and jittered. Defaults to 100ms.
+	RetryBaseDelay time.Duration
+
+	// RetryBudget, when set, caps retries across all of the client's
+	// requests; see RetryBudget
+	RetryBudget *RetryBudget
+
+	// EnableCompression requests gzip-encoded config responses and gzips log
+	// request bodies larger than 1KB. Responses that are not compressed are
+	// handled as usual.
+	EnableCompression bool
+
+	// Decoders maps response media types, e.g. "application/x-yaml", to the
+	// function used to unmarshal configs served with them. Configs with any
+	// other type are decoded as JSON.
+	Decoders map[string]UnmarshalFunc
+
+	// Marshal, when set, serializes log payloads instead of json.Marshal,
+	// e.g. the Marshal function of a faster JSON library. It must produce
+	// JSON.
+	Marshal func(v interface{}) ([]byte, error)
+
+	// Unmarshal, when set, decodes JSON configs instead of encoding/json.
+	// UseNumber and StrictDecode then have no effect. Decoders registered
+	// for other media types are unaffected.
+	Unmarshal UnmarshalFunc
+
+	// UseNumber makes JSON configs decode numbers into interface{} values as
+	// json.Number instead of float64, so large integers keep their precision
+	UseNumber bool
+
+	// StrictDecode makes JSON configs fail to decode when they contain a key
+	// that doesn't match a field of the result struct. The error names the key.
+	StrictDecode bool
+
+	// AllowEmptyConfig makes an empty config response body reset the result
+	// to its zero value instead of failing with ErrEmptyConfig
+	AllowEmptyConfig bool
+
+	// DiskCacheDir, when set, makes the client save every fetched config to
+	// DiskCacheDir/{configID}.json and serve the last saved copy when the API
+	// is unavailable, including after a restart
+	DiskCacheDir string
+
+	// CacheTTL is how long GetConfigCached serves a config from memory before
+	// fetching it again. Cache-Control and Expires headers on the response
+	// can shorten it per config. Zero disables caching.
+	CacheTTL time.Duration
+
+	// MaxResponseBytes bounds the size of a response body the client reads
+	// into memory, after decompression; larger ones fail with
+	// ErrResponseTooLarge. Zero means DefaultMaxResponseBytes (32 MiB) and a
+	// negative value removes the limit. StreamConfig and GetConfigResponse
+	// leave reading the body to the caller and are not limited.
+	MaxResponseBytes int64
+
+	// DisableSingleflight makes concurrent GetConfigCached calls that miss
+	// the cache for the same config send independent requests instead of
+	// sharing one
+	DisableSingleflight bool
+
+	// PollJitter varies WatchConfig poll intervals randomly by up to this
+	// fraction of the interval in either direction, e.g. 0.1 for ±10%, and
+	// delays the first poll by up to the same amount. It must be between 0
+	// and 1.
+	PollJitter float64
+
+	// Batch enables batched logging when set. Log calls then buffer their
+	// payloads and return immediately; see BatchConfig.
+	Batch *BatchConfig
+
+	// ConfigRateLimit and LogRateLimit, when set, throttle config requests
+	// (including Ping) and log requests separately. Calls wait for a token,
+	// or until their context is done; retries don't take extra tokens.
+	ConfigRateLimit *RateLimit
+	LogRateLimit    *RateLimit
+
+	// CircuitBreaker, when set, makes requests fail fast with ErrCircuitOpen
+	// after repeated failures; see CircuitBreakerConfig
+	CircuitBreaker *CircuitBreakerConfig
+
+	// ErrorHandler, when set, is called with errors from log deliveries that
+	// happen in the background and cannot be returned to the caller
+	ErrorHandler func(error)
+
+	// MinLevel drops messages below this level client-side before any
+	// request is made. Empty sends every level. See also Client.SetMinLevel.
+	MinLevel LogLevel
+
+	// MaxMessageBytes limits the size of log messages. Longer messages are
+	// truncated at a UTF-8 boundary and end with "…". Zero means no limit.
+	MaxMessageBytes int
+
+	// DisableTruncation makes log calls with messages over MaxMessageBytes
+	// fail with ErrMessageTooLarge instead of truncating them
+	DisableTruncation bool
+
+	// SampleRate sets the fraction of messages sent per level, between 0 and
+	// 1, e.g. 0.1 for debug keeps one in ten debug lines. Levels not listed
+	// are always sent. See Client.SampledOut for the number dropped.
+	SampleRate map[LogLevel]float64
+
+	// ErrorStacks makes ErrorErr attach the caller's stack trace to the log
+	// entry in the "stack" field
+	ErrorStacks bool
+
+	// DefaultFields are attached to every log payload sent by the client.
+	// Fields from ContextWithFields and those passed to LogFields take
+	// precedence on key collisions.
+	DefaultFields map[string]interface{}
+
+	// HostMetadata adds the "hostname" and "pid" of the process, read once
+	// when the client is created, to the default fields. DefaultFields with
+	// the same keys take precedence.
+	HostMetadata bool
+
+	// Headers are added to every request, e.g. a gateway tenant header.
+	// They never replace the App-ID, App-Secret or Content-Type headers.
+	Headers http.Header
+
+	// Tracer, when set, records a span for every config fetch and log request
+	// and propagates the trace context in request headers
+	Tracer Tracer
+
+	// Hooks, when set, are notified around every API operation for metrics
+	Hooks RequestHooks
+
+	// RequestIDs makes the client send a random X-Request-Id header with
+	// every request that doesn't already have one. Errors include the ID,
+	// preferring the one echoed back by the server.
+	RequestIDs bool
+
+	// IdempotencyKeys makes the client send a random Idempotency-Key header
+	// with every log write, the same for all retries of that write, so the
+	// server can drop duplicates of entries it already accepted. Since
+	// retrying is then safe, log writes are also retried on 429 and 5xx
+	// responses like config reads.
+	IdempotencyKeys bool
+
+	// UserAgent identifies the calling service, e.g. "billing/2.3.1". It is
+	// sent ahead of the library's own "confish-go/<Version>" User-Agent.
+	UserAgent string
+
+	// DryRun makes the client write log entries as JSON lines to
+	// DryRunWriter instead of sending them. Config requests are unaffected.
+	DryRun bool
+
+	// DryRunWriter receives log entries in dry-run mode. Defaults to os.Stderr.
+	DryRunWriter io.Writer
+
+	// AsyncQueueSize bounds the number of LogAsync entries waiting to be
+	// sent. Defaults to 1000.
+	AsyncQueueSize int
+
+	// LogDedupWindow, when positive, collapses identical consecutive log
+	// entries, by level and message, sent to the default stream. The first
+	// entry is sent at once; repeats within the window that follows are
+	// dropped and counted, and when the window closes or a different entry
+	// arrives, one more copy of the first entry is sent with the number of
+	// repeats in its DedupCountField field. LogTo, LogWithURL, LogMany and
+	// stream loggers are not deduplicated.
+	LogDedupWindow time.Duration
+
+	// clock replaces the real clock in tests, see withClock
+	clock clock
+
+	// DebugLogger, when set, is called once per HTTP attempt with the method,
+	// URL, status and duration. Headers and bodies are never logged, so the
+	// app secret doesn't leak.
+	DebugLogger func(format string, args ...interface{})
 }
 
 // Client represents a confish client for configuration and logging
 type Client struct {
-	cfg *ConfishConfig
+	*clientCore
+
+	defaultFields map[string]interface{}
+}
+
+// clientCore is the state a Client shares with the children created by With
+type clientCore struct {
+	cfg     *ConfishConfig
+	baseURL *url.URL
+	http    *http.Client
+	clock   clock
+	cache   *configCache
+	flights *fetchGroup
+	etags   *etagStore
+	batcher *logBatcher
+	dedup   *logDeduper
+	closed  atomic.Bool
+
+	configLimiter *tokenBucket
+	logLimiter    *tokenBucket
+	breaker       *circuitBreaker
+	retryBudget   *retryBudget
+
+	minLevel   atomic.Int32
+	sampleRate map[LogLevel]float64
+	sampledOut atomic.Uint64
+
+	credsMu    sync.RWMutex
+	creds      appCredentials
+	authorizer Authorizer
+
+	asyncOnce sync.Once
+	async     *asyncLogger
+
+	dryRunMu sync.Mutex
+
+	shutdownMu sync.Mutex
+	shutdown   *shutdownHook
 }
 
 // LogLevel represents the logging level
@@ -39,8 +320,9 @@ const (
 
 // LogPayload represents the payload for the logging endpoint
 type LogPayload struct {
-	Level   LogLevel `json:"level"`
-	Message string   `json:"message"`
+	Level   LogLevel               `json:"level"`
+	Message string                 `json:"message"`
+	Fields  map[string]interface{} `json:"fields,omitempty"`
 }
 
 // NewClient creates a new Confish client
@@ -53,118 +335,699 @@ func NewClient(cfg *ConfishConfig) (*Client, error) {
 		return nil, errors.New("config.URL cannot be empty")
 	}
 
+	baseURL, err := url.Parse(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("config.URL is not a valid URL: %w", err)
+	}
+
+	if baseURL.Scheme != "http" && baseURL.Scheme != "https" {
+		return nil, fmt.Errorf("config.URL must use the http or https scheme, got %q", cfg.URL)
+	}
+
+	if baseURL.Host == "" {
+		return nil, fmt.Errorf("config.URL must include a host, got %q", cfg.URL)
+	}
+
 	if cfg.AppID == "" {
 		return nil, errors.New("config.AppID cannot be empty")
 	}
 
-	if cfg.AppSecret == "" {
+	appSecret := cfg.AppSecret
+	if cfg.AppSecretFile != "" {
+		if cfg.AppSecret != "" {
+			return nil, errors.New("config.AppSecret and config.AppSecretFile cannot both be set")
+		}
+
+		secret, err := readSecretFile(cfg.AppSecretFile)
+		if err != nil {
+			return nil, fmt.Errorf("config.AppSecretFile: %w", err)
+		}
+		appSecret = secret
+	}
+
+	if cfg.Authorizer != nil && cfg.TokenSource != nil {
+		return nil, errors.New("config.Authorizer and config.TokenSource cannot both be set")
+	}
+
+	if appSecret == "" && cfg.Authorizer == nil && cfg.TokenSource == nil {
 		return nil, errors.New("config.AppSecret cannot be empty")
 	}
 
-	return &Client{cfg: cfg}, nil
+	if cfg.PollJitter < 0 || cfg.PollJitter > 1 {
+		return nil, fmt.Errorf("config.PollJitter must be between 0 and 1, got %v", cfg.PollJitter)
+	}
+
+	if cfg.ConfigPathTemplate != "" && !strings.Contains(cfg.ConfigPathTemplate, "{id}") {
+		return nil, fmt.Errorf("config.ConfigPathTemplate must contain {id}, got %q", cfg.ConfigPathTemplate)
+	}
+
+	if cfg.LogPathTemplate != "" && !strings.Contains(cfg.LogPathTemplate, "{appID}") {
+		return nil, fmt.Errorf("config.LogPathTemplate must contain {appID}, got %q", cfg.LogPathTemplate)
+	}
+
+	if cfg.MinLevel != "" && !cfg.MinLevel.Valid() {
+		return nil, fmt.Errorf("config.MinLevel: %w: %q", ErrInvalidLogLevel, cfg.MinLevel)
+	}
+
+	// Work on a copy so later changes to the caller's struct don't race with requests
+	clientCfg := *cfg
+	clientCfg.URL = strings.TrimRight(cfg.URL, "/")
+	clientCfg.WebhookPath = normalizePath(cfg.WebhookPath)
+	if clientCfg.ConfigPathTemplate == "" {
+		clientCfg.ConfigPathTemplate = DefaultConfigPathTemplate
+	}
+	if clientCfg.LogPathTemplate == "" {
+		clientCfg.LogPathTemplate = DefaultLogPathTemplate
+	}
+
+	if clientCfg.clock == nil {
+		clientCfg.clock = realClock{}
+	}
+
+	httpClient, err := newHTTPClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	c := &Client{
+		clientCore: &clientCore{
+			cfg:           &clientCfg,
+			baseURL:       baseURL,
+			http:          httpClient,
+			creds:         appCredentials{id: cfg.AppID, secret: appSecret},
+			cache:         newConfigCache(),
+			flights:       newFetchGroup(),
+			etags:         newETagStore(),
+			sampleRate:    copySampleRate(cfg.SampleRate),
+			clock:         clientCfg.clock,
+			configLimiter: newTokenBucket(cfg.ConfigRateLimit, clientCfg.clock),
+			logLimiter:    newTokenBucket(cfg.LogRateLimit, clientCfg.clock),
+			breaker:       newCircuitBreaker(cfg.CircuitBreaker, clientCfg.clock),
+			retryBudget:   newRetryBudget(cfg.RetryBudget),
+		},
+		defaultFields: mergeFields(hostFields(cfg.HostMetadata), cfg.DefaultFields),
+	}
+	switch {
+	case cfg.Authorizer != nil:
+		c.authorizer = cfg.Authorizer
+	case cfg.TokenSource != nil:
+		c.authorizer = newTokenSourceAuthorizer(cfg.TokenSource, c.clock)
+	default:
+		c.authorizer = appSecretAuthorizer{client: c}
+	}
+	c.SetMinLevel(cfg.MinLevel)
+	c.dedup = newLogDeduper(c, cfg.LogDedupWindow)
+	if cfg.Batch != nil {
+		c.batcher = newLogBatcher(c, *cfg.Batch)
+	}
+
+	return c, nil
+}
+
+// handleError reports a background error to the configured ErrorHandler
+func (c *Client) handleError(err error) {
+	if c.cfg.ErrorHandler != nil {
+		c.cfg.ErrorHandler(err)
+	}
+}
+
+// endpoint builds an API URL by appending path segments to the base URL,
+// keeping any path prefix it has and escaping each segment
+func (c *Client) endpoint(segments ...string) string {
+	escaped := make([]string, len(segments))
+	for i, segment := range segments {
+		escaped[i] = url.PathEscape(segment)
+	}
+	return c.baseURL.JoinPath(escaped...).String()
+}
+
+// Default API path templates; see ConfishConfig.ConfigPathTemplate
+const (
+	DefaultConfigPathTemplate = "/c/{id}"
+	DefaultLogPathTemplate    = "/a/{appID}/log"
+)
+
+// ErrInvalidConfigID is returned for config IDs that are empty, "." or "..",
+// which would make the request path point at another resource
+var ErrInvalidConfigID = errors.New("confish: invalid config ID")
+
+// configEndpoint returns the URL of a config
+func (c *Client) configEndpoint(configID string) (string, error) {
+	switch configID {
+	case "", ".", "..":
+		return "", fmt.Errorf("%w: %q", ErrInvalidConfigID, configID)
+	}
+	return c.expandPath(c.cfg.ConfigPathTemplate, configID).String(), nil
+}
+
+// logEndpoint returns the URL of the app's log endpoint with any sub-path
+// segments appended
+func (c *Client) logEndpoint(segments ...string) string {
+	escaped := make([]string, len(segments))
+	for i, segment := range segments {
+		escaped[i] = url.PathEscape(segment)
+	}
+	return c.expandPath(c.cfg.LogPathTemplate, "").JoinPath(escaped...).String()
+}
+
+// expandPath fills in the placeholders of a path template, escaping their
+// values, and appends the result to the base URL
+func (c *Client) expandPath(template, configID string) *url.URL {
+	expanded := strings.NewReplacer(
+		"{id}", url.PathEscape(configID),
+		"{appID}", url.PathEscape(c.appID()),
+	).Replace(template)
+	return c.baseURL.JoinPath(expanded)
+}
+
+// normalizePath gives a URL path a leading slash and no trailing slash so that
+// it compares equal to incoming request paths
+func normalizePath(p string) string {
+	if p == "" {
+		return ""
+	}
+	return path.Clean("/" + p)
+}
+
+// httpClient returns the configured HTTP client or the shared default
+func (c *Client) httpClient() *http.Client {
+	return c.http
+}
+
+// withTimeout derives a context bounded by the configured request timeout
+func (c *Client) withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.cfg.Timeout <= 0 {
+		return context.WithCancel(ctx)
+	}
+	return context.WithTimeout(ctx, c.cfg.Timeout)
+}
+
+// newRequest builds a request against the Confish API with the auth headers set
+func (c *Client) newRequest(ctx context.Context, method, url string, body []byte) (*http.Request, error) {
+	compressBody := c.cfg.EnableCompression && len(body) >= compressionThreshold
+	if compressBody {
+		compressed, err := gzipBytes(body)
+		if err != nil {
+			return nil, fmt.Errorf("failed to compress request body: %w", err)
+		}
+		body = compressed
+	}
+
+	var bodyReader io.Reader
+	if body != nil {
+		bodyReader = bytes.NewReader(body)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bodyReader)
+	if err != nil {
+		return nil, err
+	}
+
+	// Add headers, letting the client's own values override custom ones
+	c.applyCustomHeaders(req)
+	c.injectTrace(req)
+	if err := c.authorizer.Apply(req); err != nil {
+		return nil, &authorizeError{err: err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", c.userAgent())
+	if compressBody {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	if c.cfg.EnableCompression && method == "GET" {
+		req.Header.Set("Accept-Encoding", "gzip")
+	}
+	if c.cfg.RequestIDs && req.Header.Get(RequestIDHeader) == "" {
+		id, err := newRequestID()
+		if err != nil {
+			return nil, err
+		}
+		req.Header.Set(RequestIDHeader, id)
+	}
+
+	return req, nil
 }
 
-// GetConfig retrieves a configuration from the Confish API and unmarshals it into the provided type
+// GetConfig retrieves a configuration from the Confish API and unmarshals it into the provided type.
+// An empty response body fails with ErrEmptyConfig unless AllowEmptyConfig is
+// set. A literal JSON null leaves result unchanged, as with json.Unmarshal.
 func (c *Client) GetConfig(configID string, result interface{}) error {
-	url := fmt.Sprintf("%s/c/%s", c.cfg.URL, configID)
-	req, err := http.NewRequest("GET", url, nil)
+	return c.GetConfigContext(context.Background(), configID, result)
+}
+
+// GetConfigContext is like GetConfig but aborts the request when ctx is cancelled or its deadline passes
+func (c *Client) GetConfigContext(ctx context.Context, configID string, result interface{}) error {
+	if err := checkTarget(result); err != nil {
+		return err
+	}
+
+	body, meta, err := c.fetchConfig(ctx, configID)
 	if err != nil {
-		return fmt.Errorf("failed to create request: %w", err)
+		return err
 	}
 
-	// Add headers
-	req.Header.Add("App-ID", c.cfg.AppID)
-	req.Header.Add("App-Secret", c.cfg.AppSecret)
-	req.Header.Add("Content-Type", "application/json")
+	return c.decodeConfig(configID, body, meta.ContentType, result)
+}
+
+// ConfigMeta describes how a config fetched by GetConfigWithMeta was obtained
+type ConfigMeta struct {
+	// FromCache is true when the server answered 304 Not Modified and the
+	// previously fetched body was used
+	FromCache bool
+	// FromDisk is true when the API was unavailable and the config was read
+	// from the disk cache
+	FromDisk bool
+	// ETag is the entity tag of the returned config, if the server sent one
+	ETag string
+	// Version identifies the revision of the config; see GetConfigVersioned
+	Version string
+	// ContentType is the media type the config was served as. For a config
+	// read from the disk cache it is the one saved with it.
+	ContentType string
+	// StatusCode is the HTTP status of the response. Any 2xx is a success,
+	// so it may be e.g. 203 when a gateway served the config from its cache.
+	StatusCode int
+	// Header holds the response headers, e.g. X-Request-Id or
+	// X-RateLimit-Remaining
+	Header http.Header
+}
+
+// GetConfigWithMeta is like GetConfig but also reports whether the config
+// came from the network or from the client's ETag store, along with the
+// response status and headers. The meta is filled in on error too when a
+// response was received.
+func (c *Client) GetConfigWithMeta(configID string, result interface{}) (ConfigMeta, error) {
+	return c.GetConfigWithMetaContext(context.Background(), configID, result)
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+// GetConfigWithMetaContext is like GetConfigWithMeta but aborts the request when ctx is cancelled or its deadline passes
+func (c *Client) GetConfigWithMetaContext(ctx context.Context, configID string, result interface{}) (ConfigMeta, error) {
+	if err := checkTarget(result); err != nil {
+		return ConfigMeta{}, err
+	}
+
+	body, meta, err := c.fetchConfig(ctx, configID)
+	if err != nil {
+		return meta, err
+	}
+
+	return meta, c.decodeConfig(configID, body, meta.ContentType, result)
+}
+
+// fetchConfig returns the raw body of a config, from the API or, when the API
+// is unavailable and a disk cache is configured, from the last copy on disk
+func (c *Client) fetchConfig(ctx context.Context, configID string) ([]byte, ConfigMeta, error) {
+	body, meta, err := c.fetchConfigHTTP(ctx, configID)
+	if err != nil {
+		err = c.configError(configID, err)
+	}
+	if c.cfg.DiskCacheDir == "" {
+		return body, meta, err
+	}
+
+	if err == nil {
+		if writeErr := c.writeDiskCache(configID, body, meta.ContentType); writeErr != nil {
+			c.handleError(writeErr)
+		}
+		return body, meta, nil
+	}
+
+	if !isUnavailable(ctx, err) {
+		return nil, meta, err
+	}
+
+	cached, contentType, readErr := c.readDiskCache(configID)
+	if readErr != nil {
+		return nil, meta, err
+	}
+
+	return cached, ConfigMeta{FromDisk: true, ContentType: contentType}, nil
+}
+
+// fetchConfigHTTP performs the config request and returns the raw response body.
+// When the server sent an ETag for an earlier fetch of the same config, the
+// request is made conditional and a 304 response yields the stored body.
+func (c *Client) fetchConfigHTTP(ctx context.Context, configID string) (body []byte, meta ConfigMeta, err error) {
+	ctx, endSpan := c.startSpan(ctx, SpanGetConfig, map[string]string{"confish.config_id": configID})
+	endObserve := c.observe(OpGetConfig)
+	defer func() {
+		endObserve(meta.StatusCode, err)
+		endSpan(meta.StatusCode, err)
+	}()
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	req, err := c.newConfigRequest(ctx, configID)
 	if err != nil {
-		return fmt.Errorf("failed to fetch config: %w", err)
+		return nil, ConfigMeta{}, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	cached, hasCached := c.etags.get(configID)
+	if hasCached {
+		req.Header.Set("If-None-Match", cached.etag)
+	}
+
+	if err := c.configLimiter.wait(ctx); err != nil {
+		return nil, ConfigMeta{}, fmt.Errorf("failed to fetch config: %w", err)
+	}
+
+	resp, err := c.do(req, true)
+	if err != nil {
+		return nil, ConfigMeta{}, fmt.Errorf("failed to fetch config: %w", err)
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("received non-OK response: %d, body: %s", resp.StatusCode, string(bodyBytes))
+	meta = ConfigMeta{StatusCode: resp.StatusCode, Header: resp.Header}
+
+	if resp.StatusCode == http.StatusNotModified && hasCached {
+		meta.FromCache = true
+		meta.ETag = cached.etag
+		meta.ContentType = cached.contentType
+		meta.Version = configVersion(resp.Header, cached.etag)
+		if meta.Version == "" {
+			meta.Version = cached.version
+		}
+		return bytes.Clone(cached.body), meta, nil
+	}
+
+	if resp.StatusCode == http.StatusTooManyRequests {
+		retryAfter, _ := parseRetryAfter(resp.Header.Get("Retry-After"), c.clock.Now())
+		return nil, meta, &RateLimitError{RetryAfter: retryAfter, HTTPError: newHTTPError(resp, nil)}
+	}
+
+	if !isSuccess(resp.StatusCode) {
+		return nil, meta, newHTTPError(resp, ErrConfigNotFound)
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, err = c.readBody(resp.Body)
 	if err != nil {
-		return fmt.Errorf("failed to read response body: %w", err)
+		return nil, meta, fmt.Errorf("failed to read response body: %w", err)
 	}
 
-	if err := json.Unmarshal(body, result); err != nil {
-		return fmt.Errorf("failed to unmarshal config: %w", err)
+	meta.ContentType = resp.Header.Get("Content-Type")
+	meta.ETag = resp.Header.Get("ETag")
+	meta.Version = configVersion(resp.Header, meta.ETag)
+	if meta.ETag != "" {
+		c.etags.set(configID, etagEntry{etag: meta.ETag, contentType: meta.ContentType, version: meta.Version, body: body})
+	} else {
+		c.etags.delete(configID)
 	}
 
-	return nil
+	return body, meta, nil
+}
+
+// newConfigRequest builds the authenticated GET request for a config, asking
+// for the media type chosen by acceptFor
+func (c *Client) newConfigRequest(ctx context.Context, configID string) (*http.Request, error) {
+	endpoint, err := c.configEndpoint(configID)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := c.newRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Set("Accept", c.acceptFor(ctx))
+	return req, nil
+}
+
+// GetConfigResponse performs the config request and returns the raw HTTP
+// response without checking its status or reading its body. The caller must
+// close the body.
+func (c *Client) GetConfigResponse(configID string) (*http.Response, error) {
+	return c.GetConfigResponseContext(context.Background(), configID)
+}
+
+// GetConfigResponseContext is like GetConfigResponse but aborts the request when ctx is cancelled or its deadline passes
+func (c *Client) GetConfigResponseContext(ctx context.Context, configID string) (*http.Response, error) {
+	ctx, cancel := c.withTimeout(ctx)
+
+	req, err := c.newConfigRequest(ctx, configID)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if err := c.configLimiter.wait(ctx); err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to fetch config: %w", err)
+	}
+
+	resp, err := c.do(req, true)
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("failed to fetch config: %w", err)
+	}
+
+	// Keep the timeout running until the caller is done with the body
+	resp.Body = &cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
+}
+
+// cancelOnClose releases a request context once the response body is closed
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (b *cancelOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+	return err
+}
+
+// GetConfigRaw retrieves a configuration from the Confish API and returns the
+// response body as is, without unmarshaling it
+func (c *Client) GetConfigRaw(configID string) (json.RawMessage, error) {
+	return c.GetConfigRawContext(context.Background(), configID)
+}
+
+// GetConfigRawContext is like GetConfigRaw but aborts the request when ctx is cancelled or its deadline passes
+func (c *Client) GetConfigRawContext(ctx context.Context, configID string) (json.RawMessage, error) {
+	body, _, err := c.fetchConfig(ctx, configID)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.RawMessage(body), nil
+}
+
+// GetConfigAs retrieves a configuration from the Confish API and returns it decoded as T
+func GetConfigAs[T any](c *Client, configID string) (T, error) {
+	var result T
+	err := c.GetConfig(configID, &result)
+	return result, err
 }
 
 // Log sends a log message to the Confish logging endpoint
 func (c *Client) Log(level LogLevel, message string) error {
-	return c.LogWithURL(level, message)
+	return c.LogContext(context.Background(), level, message)
 }
 
 // LogWithURL sends a log message to a specific Confish logging endpoint URL
-func (c *Client) LogWithURL(level LogLevel, message string) error {
-	payload := LogPayload{
-		Level:   level,
-		Message: message,
+// instead of the app's default endpoint. The request carries the client's
+// credentials, so only pass URLs of Confish servers you trust. It is never
+// batched.
+func (c *Client) LogWithURL(url string, level LogLevel, message string) error {
+	return c.LogWithURLContext(context.Background(), url, level, message)
+}
+
+// LogWithURLContext is like LogWithURL but aborts the request when ctx is cancelled or its deadline passes
+func (c *Client) LogWithURLContext(ctx context.Context, url string, level LogLevel, message string) error {
+	if url == "" {
+		return errors.New("log URL cannot be empty")
 	}
 
-	jsonPayload, err := json.Marshal(payload)
-	if err != nil {
-		return fmt.Errorf("failed to marshal log payload: %w", err)
+	return c.logTo(ctx, url, level, message, nil)
+}
+
+// LogContext is like Log but aborts the request when ctx is cancelled or its deadline passes
+func (c *Client) LogContext(ctx context.Context, level LogLevel, message string) error {
+	return c.LogFieldsContext(ctx, level, message, nil)
+}
+
+// LogFields sends a log message with structured key/value fields attached
+func (c *Client) LogFields(level LogLevel, message string, fields map[string]interface{}) error {
+	return c.LogFieldsContext(context.Background(), level, message, fields)
+}
+
+// LogFieldsContext is like LogFields but aborts the request when ctx is cancelled or its deadline passes
+func (c *Client) LogFieldsContext(ctx context.Context, level LogLevel, message string, fields map[string]interface{}) error {
+	return c.logTo(ctx, "", level, message, fields)
+}
+
+// LogTo sends a log message to a named log stream of the app, such as
+// "audit", which is kept apart from the default stream. Stream messages are
+// always sent immediately, even when batching is enabled. An empty stream
+// is the default stream, like Log.
+func (c *Client) LogTo(stream string, level LogLevel, message string) error {
+	return c.LogToContext(context.Background(), stream, level, message)
+}
+
+// LogToContext is like LogTo but aborts the request when ctx is cancelled or its deadline passes
+func (c *Client) LogToContext(ctx context.Context, stream string, level LogLevel, message string) error {
+	if stream == "" {
+		return c.LogContext(ctx, level, message)
+	}
+
+	return c.logTo(ctx, c.logEndpoint(stream), level, message, nil)
+}
+
+// logTo filters, builds and delivers a log message to the endpoint url, or
+// through the default endpoint when url is empty
+func (c *Client) logTo(ctx context.Context, url string, level LogLevel, message string, fields map[string]interface{}) error {
+	if c.closed.Load() {
+		return ErrClientClosed
+	}
+
+	if !level.Valid() {
+		return fmt.Errorf("%w: %q", ErrInvalidLogLevel, level)
 	}
 
-	url := fmt.Sprintf("%s/a/%s/log", c.cfg.URL, c.cfg.AppID)
-	req, err := http.NewRequest("POST", url, bytes.NewBuffer(jsonPayload))
+	if !c.levelEnabled(level) || !c.sampled(level) {
+		return nil
+	}
+
+	payload, err := c.newPayload(ctx, level, message, fields)
 	if err != nil {
-		return fmt.Errorf("failed to create log request: %w", err)
+		return err
 	}
 
-	// Add headers
-	req.Header.Add("App-ID", c.cfg.AppID)
-	req.Header.Add("App-Secret", c.cfg.AppSecret)
-	req.Header.Add("Content-Type", "application/json")
+	if url != "" {
+		return c.postLog(ctx, OpLog, url, payload, nil)
+	}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
+	return c.sendPayload(ctx, payload)
+}
+
+// newPayload builds a log payload with the client's default fields and those
+// of ctx merged in and the message size limit applied
+func (c *Client) newPayload(ctx context.Context, level LogLevel, message string, fields map[string]interface{}) (LogPayload, error) {
+	message, err := c.limitMessage(message)
 	if err != nil {
-		return fmt.Errorf("failed to send log: %w", err)
+		return LogPayload{}, err
 	}
-	defer resp.Body.Close()
 
-	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
-		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("received non-OK response for log: %d, body: %s", resp.StatusCode, string(bodyBytes))
+	return LogPayload{
+		Level:   level,
+		Message: message,
+		Fields:  mergeFields(mergeFields(c.defaultFields, FieldsFromContext(ctx)), fields),
+	}, nil
+}
+
+// With returns a child client that adds fields to every log entry, e.g. a
+// request's trace ID, on top of the default fields; fields given per call or
+// in the context still take precedence. The child shares everything else
+// with c, including its connections, caches, queues and credentials, so it
+// is cheap to create per request. Closing either closes both.
+func (c *Client) With(fields map[string]interface{}) *Client {
+	return &Client{
+		clientCore:    c.clientCore,
+		defaultFields: mergeFields(c.defaultFields, fields),
 	}
+}
 
-	return nil
+// mergeFields returns a new map with the entries of base overridden by those
+// of override, or nil when both are empty
+func mergeFields(base, override map[string]interface{}) map[string]interface{} {
+	if len(base) == 0 && len(override) == 0 {
+		return nil
+	}
+
+	merged := make(map[string]interface{}, len(base)+len(override))
+	for k, v := range base {
+		merged[k] = v
+	}
+	for k, v := range override {
+		merged[k] = v
+	}
+
+	return merged
 }
 
-// WebhookPayload represents a webhook payload type received from confish
-type WebhookPayload struct {
-	Event         string              `json:"event"`
-	Configuration ConfigurationObject `json:"configuration"`
+// sendPayload delivers a log payload unless deduplication collapses it
+func (c *Client) sendPayload(ctx context.Context, payload LogPayload) error {
+	send, summary := c.dedup.check(payload)
+	if summary != nil {
+		if err := c.deliverPayload(ctx, *summary); err != nil {
+			return err
+		}
+	}
+	if !send {
+		return nil
+	}
+
+	return c.deliverPayload(ctx, payload)
 }
 
-// ConfigurationObject represents a configuration object received from confish
-type ConfigurationObject struct {
-	Name   string          `json:"name"`
-	Values json.RawMessage `json:"values"`
+// deliverPayload sends a log payload, either directly or through the batcher
+func (c *Client) deliverPayload(ctx context.Context, payload LogPayload) error {
+	if c.batcher != nil {
+		return c.batcher.enqueue(ctx, payload)
+	}
+
+	return c.postLog(ctx, OpLog, c.logEndpoint(), payload, nil)
 }
 
-// ProcessWebhookPayload processes a webhook payload and returns the configuration values
-func (c *Client) ProcessWebhookPayload(payload WebhookPayload, result interface{}) error {
-	// Check if this is a configuration update event
-	if payload.Event != "configuration.updated" {
-		return fmt.Errorf("unsupported event type: %s", payload.Event)
+// postLog sends v as JSON to a logging endpoint. When out is non-nil, a JSON
+// response body is decoded into it.
+func (c *Client) postLog(ctx context.Context, op, url string, v, out interface{}) (err error) {
+	if c.cfg.DryRun {
+		return c.writeDryRun(v)
+	}
+
+	var status int
+	ctx, endSpan := c.startSpan(ctx, SpanLog, map[string]string{"confish.app_id": c.appID()})
+	endObserve := c.observe(op)
+	defer func() {
+		endObserve(status, err)
+		endSpan(status, err)
+	}()
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	jsonPayload, err := c.marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal log payload: %w", err)
+	}
+
+	req, err := c.newRequest(ctx, "POST", url, jsonPayload)
+	if err != nil {
+		return fmt.Errorf("failed to create log request: %w", err)
+	}
+	if err := c.setIdempotencyKey(req); err != nil {
+		return fmt.Errorf("failed to create log request: %w", err)
+	}
+
+	if err := c.logLimiter.wait(ctx); err != nil {
+		return fmt.Errorf("failed to send log: %w", err)
+	}
+
+	resp, err := c.do(req, c.cfg.IdempotencyKeys)
+	if err != nil {
+		return fmt.Errorf("failed to send log: %w", err)
+	}
+	defer resp.Body.Close()
+
+	status = resp.StatusCode
+	if !isSuccess(resp.StatusCode) {
+		return fmt.Errorf("failed to send log: %w", newHTTPError(resp, nil))
 	}
 
-	if err := json.Unmarshal(payload.Configuration.Values, result); err != nil {
-		return fmt.Errorf("failed to unmarshal configuration values: %w", err)
+	if out != nil {
+		body, err := c.readBody(resp.Body)
+		if err != nil {
+			return fmt.Errorf("failed to read log response body: %w", err)
+		}
+		if len(bytes.TrimSpace(body)) > 0 {
+			if err := json.Unmarshal(body, out); err != nil {
+				return fmt.Errorf("failed to unmarshal log response: %w", err)
+			}
+		}
 	}
 
 	return nil
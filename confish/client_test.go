@@ -0,0 +1,208 @@
+package confish_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/bravilogy/confish-go/confish"
+)
+
+// recordPaths starts a server that answers every request with an empty JSON
+// object and records the request paths
+func recordPaths(t *testing.T) (*httptest.Server, func() []string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		paths = append(paths, r.URL.EscapedPath())
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), paths...)
+	}
+}
+
+func TestBaseURLPathJoining(t *testing.T) {
+	tests := []struct {
+		name       string
+		suffix     string
+		configPath string
+		logPath    string
+	}{
+		{"no prefix", "", "/c/flags", "/a/app/log"},
+		{"trailing slash", "/", "/c/flags", "/a/app/log"},
+		{"prefix", "/api/confish", "/api/confish/c/flags", "/api/confish/a/app/log"},
+		{"prefix with trailing slash", "/api/confish/", "/api/confish/c/flags", "/api/confish/a/app/log"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, paths := recordPaths(t)
+
+			client, err := confish.NewClientWithOptions(server.URL+tt.suffix, "app", "secret")
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var result map[string]interface{}
+			if err := client.GetConfig("flags", &result); err != nil {
+				t.Fatalf("GetConfig() error = %v", err)
+			}
+			if err := client.Info("hello"); err != nil {
+				t.Fatalf("Info() error = %v", err)
+			}
+
+			got := paths()
+			if len(got) != 2 || got[0] != tt.configPath || got[1] != tt.logPath {
+				t.Errorf("request paths = %q, want [%q %q]", got, tt.configPath, tt.logPath)
+			}
+		})
+	}
+}
+
+func TestConfigIDIsEscaped(t *testing.T) {
+	server, paths := recordPaths(t)
+
+	client, err := confish.NewClientWithOptions(server.URL+"/api", "app", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result map[string]interface{}
+	if err := client.GetConfig("a b/c", &result); err != nil {
+		t.Fatalf("GetConfig() error = %v", err)
+	}
+
+	if got := paths(); len(got) != 1 || got[0] != "/api/c/a%20b%2Fc" {
+		t.Errorf("request paths = %q, want [/api/c/a%%20b%%2Fc]", got)
+	}
+}
+
+func TestInvalidConfigIDs(t *testing.T) {
+	server, paths := recordPaths(t)
+
+	client, err := confish.NewClientWithOptions(server.URL, "app", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, id := range []string{"", ".", ".."} {
+		var result map[string]interface{}
+		if err := client.GetConfig(id, &result); !errors.Is(err, confish.ErrInvalidConfigID) {
+			t.Errorf("GetConfig(%q) error = %v, want ErrInvalidConfigID", id, err)
+		}
+		if _, err := client.ConfigExists(id); !errors.Is(err, confish.ErrInvalidConfigID) {
+			t.Errorf("ConfigExists(%q) error = %v, want ErrInvalidConfigID", id, err)
+		}
+		if err := client.UpdateConfig(id, result); !errors.Is(err, confish.ErrInvalidConfigID) {
+			t.Errorf("UpdateConfig(%q) error = %v, want ErrInvalidConfigID", id, err)
+		}
+	}
+
+	if got := paths(); len(got) != 0 {
+		t.Errorf("request paths = %q, want none", got)
+	}
+}
+
+// newBenchServer starts a server answering every request with an empty JSON
+// object and returns its URL
+func newBenchServer(b *testing.B) string {
+	b.Helper()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{}`))
+	}))
+	b.Cleanup(server.Close)
+	return server.URL
+}
+
+func BenchmarkGetConfig(b *testing.B) {
+	client, err := confish.NewClientWithOptions(newBenchServer(b), "app", "secret")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		var result map[string]interface{}
+		if err := client.GetConfig("flags", &result); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkGetConfigPerCallTransport measures the previous behaviour of a new
+// transport, and so a new connection, for every call
+func BenchmarkGetConfigPerCallTransport(b *testing.B) {
+	url := newBenchServer(b)
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		client, err := confish.NewClientWithOptions(url, "app", "secret", confish.WithHTTPClient(&http.Client{Transport: transport}))
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		var result map[string]interface{}
+		if err := client.GetConfig("flags", &result); err != nil {
+			b.Fatal(err)
+		}
+		transport.CloseIdleConnections()
+	}
+}
+
+func BenchmarkLog(b *testing.B) {
+	client, err := confish.NewClientWithOptions(newBenchServer(b), "app", "secret")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		if err := client.Info("hello"); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// BenchmarkLogMarshaler compares the default log serialization with the same
+// encoder injected through WithMarshaler, to show the hook's overhead
+func BenchmarkLogMarshaler(b *testing.B) {
+	url := newBenchServer(b)
+
+	for _, bm := range []struct {
+		name string
+		opts []confish.Option
+	}{
+		{"Default", nil},
+		{"WithMarshaler", []confish.Option{confish.WithMarshaler(json.Marshal)}},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			client, err := confish.NewClientWithOptions(url, "app", "secret", bm.opts...)
+			if err != nil {
+				b.Fatal(err)
+			}
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				if err := client.Info("hello"); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package confish
+
+import (
+	"context"
+	"time"
+)
+
+// clock is the source of time for cache expiry, backoff, rate limiting, the
+// circuit breaker cooldown and flush and poll intervals. Tests replace it with
+// withClock to control time without sleeping. Request durations reported to
+// hooks and the debug logger are always measured with the real clock.
+type clock interface {
+	Now() time.Time
+	NewTimer(d time.Duration) clockTimer
+	NewTicker(d time.Duration) clockTicker
+}
+
+// clockTimer is the part of *time.Timer the client uses
+type clockTimer interface {
+	C() <-chan time.Time
+	Stop() bool
+}
+
+// clockTicker is the part of *time.Ticker the client uses
+type clockTicker interface {
+	C() <-chan time.Time
+	Stop()
+}
+
+// realClock is the clock of the time package
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+func (realClock) NewTimer(d time.Duration) clockTimer { return realTimer{time.NewTimer(d)} }
+
+func (realClock) NewTicker(d time.Duration) clockTicker { return realTicker{time.NewTicker(d)} }
+
+type realTimer struct{ *time.Timer }
+
+func (t realTimer) C() <-chan time.Time { return t.Timer.C }
+
+type realTicker struct{ *time.Ticker }
+
+func (t realTicker) C() <-chan time.Time { return t.Ticker.C }
+
+// withClock makes the client use clk instead of the real clock. It is meant
+// for tests of time-dependent behaviour.
+func withClock(clk clock) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.clock = clk
+	}
+}
+
+// sleepContext waits for d on clk or until ctx is done, whichever comes first
+func sleepContext(ctx context.Context, clk clock, d time.Duration) error {
+	timer := clk.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C():
+		return nil
+	}
+}
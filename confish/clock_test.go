@@ -0,0 +1,325 @@
+package confish
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock is a clock that only moves when advanced. Every timer it creates
+// is reported on timers so tests can wait for the client to start sleeping,
+// and every ticker on tickers.
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	pending []*fakeTimer
+	running []*fakeTicker
+	timers  chan time.Duration
+	tickers chan time.Duration
+}
+
+func newFakeClock() *fakeClock {
+	return &fakeClock{
+		now:     time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		timers:  make(chan time.Duration, 16),
+		tickers: make(chan time.Duration, 16),
+	}
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) NewTimer(d time.Duration) clockTimer {
+	c.mu.Lock()
+	t := &fakeTimer{clock: c, deadline: c.now.Add(d), c: make(chan time.Time, 1)}
+	if d <= 0 {
+		t.c <- c.now
+	} else {
+		c.pending = append(c.pending, t)
+	}
+	c.mu.Unlock()
+
+	c.timers <- d
+	return t
+}
+
+func (c *fakeClock) NewTicker(d time.Duration) clockTicker {
+	c.mu.Lock()
+	t := &fakeTicker{clock: c, interval: d, next: c.now.Add(d), c: make(chan time.Time, 1)}
+	c.running = append(c.running, t)
+	c.mu.Unlock()
+
+	select {
+	case c.tickers <- d:
+	default:
+	}
+	return t
+}
+
+// Advance moves the clock forward by d, firing the timers and tickers that
+// are due. Like a time.Ticker, a ticker whose tick wasn't received yet drops
+// the ticks that follow.
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+	for _, t := range c.running {
+		if t.next.After(c.now) {
+			continue
+		}
+		select {
+		case t.c <- c.now:
+		default:
+		}
+		for !t.next.After(c.now) {
+			t.next = t.next.Add(t.interval)
+		}
+	}
+	pending := c.pending[:0]
+	for _, t := range c.pending {
+		if t.deadline.After(c.now) {
+			pending = append(pending, t)
+			continue
+		}
+		t.c <- c.now
+	}
+	c.pending = pending
+}
+
+// waitTimer returns the duration of the next timer the client creates
+func (c *fakeClock) waitTimer(t *testing.T) time.Duration {
+	t.Helper()
+	select {
+	case d := <-c.timers:
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatal("client did not start a timer")
+		return 0
+	}
+}
+
+// waitTicker returns the interval of the next ticker the client creates
+func (c *fakeClock) waitTicker(t *testing.T) time.Duration {
+	t.Helper()
+	select {
+	case d := <-c.tickers:
+		return d
+	case <-time.After(5 * time.Second):
+		t.Fatal("client did not start a ticker")
+		return 0
+	}
+}
+
+// advanceTimers fires every timer the client starts, in a goroutine, until
+// the returned stop is called. stop returns the durations of the timers.
+func (c *fakeClock) advanceTimers() (stop func() []time.Duration) {
+	var delays []time.Duration
+	quit := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			select {
+			case d := <-c.timers:
+				delays = append(delays, d)
+				c.Advance(d)
+			case <-quit:
+				return
+			}
+		}
+	}()
+
+	return func() []time.Duration {
+		close(quit)
+		<-done
+		return delays
+	}
+}
+
+type fakeTimer struct {
+	clock    *fakeClock
+	deadline time.Time
+	c        chan time.Time
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.c }
+
+func (t *fakeTimer) Stop() bool {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	for i, p := range t.clock.pending {
+		if p == t {
+			t.clock.pending = append(t.clock.pending[:i], t.clock.pending[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+type fakeTicker struct {
+	clock    *fakeClock
+	interval time.Duration
+	next     time.Time
+	c        chan time.Time
+}
+
+func (t *fakeTicker) C() <-chan time.Time { return t.c }
+
+func (t *fakeTicker) Stop() {
+	t.clock.mu.Lock()
+	defer t.clock.mu.Unlock()
+
+	for i, r := range t.clock.running {
+		if r == t {
+			t.clock.running = append(t.clock.running[:i], t.clock.running[i+1:]...)
+			return
+		}
+	}
+}
+
+func TestCacheExpiry(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		w.Write([]byte(`{"enabled":true}`))
+	}))
+	defer server.Close()
+
+	clk := newFakeClock()
+	client, err := NewClientWithOptions(server.URL, "app", "secret", WithCacheTTL(time.Minute), withClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func() {
+		t.Helper()
+		var result struct{ Enabled bool }
+		if err := client.GetConfigCached("flags", &result); err != nil || !result.Enabled {
+			t.Fatalf("GetConfigCached() = %v, %+v", err, result)
+		}
+	}
+
+	get()
+	clk.Advance(59 * time.Second)
+	get()
+	if n := requests.Load(); n != 1 {
+		t.Fatalf("requests before expiry = %d, want 1", n)
+	}
+
+	clk.Advance(time.Second)
+	get()
+	if n := requests.Load(); n != 2 {
+		t.Errorf("requests after expiry = %d, want 2", n)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if requests.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"enabled":true}`))
+	}))
+	defer server.Close()
+
+	clk := newFakeClock()
+	client, err := NewClientWithOptions(server.URL, "app", "secret", WithRetries(2, time.Second), withClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		var result struct{ Enabled bool }
+		done <- client.GetConfig("flags", &result)
+	}()
+
+	for attempt, max := range []time.Duration{time.Second, 2 * time.Second} {
+		d := clk.waitTimer(t)
+		if d < max/2 || d > max {
+			t.Errorf("backoff before retry %d = %v, want between %v and %v", attempt+1, d, max/2, max)
+		}
+		select {
+		case err := <-done:
+			t.Fatalf("GetConfig() returned while backing off: %v", err)
+		default:
+		}
+		clk.Advance(d)
+	}
+
+	if err := <-done; err != nil {
+		t.Fatalf("GetConfig() error = %v", err)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("requests = %d, want 3", n)
+	}
+}
+
+func TestCircuitBreakerCooldown(t *testing.T) {
+	var healthy atomic.Bool
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		w.Write([]byte(`{"enabled":true}`))
+	}))
+	defer server.Close()
+
+	clk := newFakeClock()
+	client, err := NewClientWithOptions(server.URL, "app", "secret",
+		WithCircuitBreaker(CircuitBreakerConfig{FailureThreshold: 2, Cooldown: 10 * time.Second}),
+		withClock(clk),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func() error {
+		var result struct{ Enabled bool }
+		return client.GetConfig("flags", &result)
+	}
+
+	for i := 0; i < 2; i++ {
+		if err := get(); err == nil || errors.Is(err, ErrCircuitOpen) {
+			t.Fatalf("GetConfig() #%d error = %v, want a server error", i+1, err)
+		}
+	}
+	if state := client.CircuitState(); state != CircuitOpen {
+		t.Fatalf("CircuitState() = %v, want open", state)
+	}
+
+	clk.Advance(9 * time.Second)
+	if err := get(); !errors.Is(err, ErrCircuitOpen) {
+		t.Fatalf("GetConfig() during cooldown error = %v, want ErrCircuitOpen", err)
+	}
+	if n := requests.Load(); n != 2 {
+		t.Fatalf("requests during cooldown = %d, want 2", n)
+	}
+
+	clk.Advance(time.Second)
+	if state := client.CircuitState(); state != CircuitHalfOpen {
+		t.Fatalf("CircuitState() after cooldown = %v, want half-open", state)
+	}
+
+	healthy.Store(true)
+	if err := get(); err != nil {
+		t.Fatalf("GetConfig() probe error = %v", err)
+	}
+	if state := client.CircuitState(); state != CircuitClosed {
+		t.Errorf("CircuitState() after probe = %v, want closed", state)
+	}
+}
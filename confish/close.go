@@ -0,0 +1,26 @@
+package confish
+
+import "context"
+
+// Close shuts the client down: it stops accepting log calls, sends everything
+// queued by LogAsync or buffered for batching, and stops the background
+// goroutines. It waits for this to finish or for ctx to be done, in which
+// case it returns ctx.Err() and pending entries may be lost. Log calls made
+// after Close return ErrClientClosed. Config fetches keep working.
+func (c *Client) Close(ctx context.Context) error {
+	c.closed.Store(true)
+
+	if err := c.stopAsync(ctx); err != nil {
+		return err
+	}
+
+	if err := c.dedup.flush(ctx); err != nil {
+		c.handleError(err)
+	}
+
+	if c.batcher != nil {
+		return c.batcher.close(ctx)
+	}
+
+	return nil
+}
@@ -0,0 +1,89 @@
+package confish_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/bravilogy/confish-go/confish"
+	"github.com/bravilogy/confish-go/confish/confishtest"
+)
+
+func TestCloseDrainsPendingLogs(t *testing.T) {
+	tests := []struct {
+		name string
+		opts []confish.Option
+	}{
+		{"async", nil},
+		{"batched", []confish.Option{confish.WithBatching(confish.BatchConfig{MaxBatchSize: 100})}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := confishtest.NewServer()
+			defer server.Close()
+
+			client, err := server.NewClient(tt.opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			for i := 0; i < 5; i++ {
+				client.LogAsync(confish.LogLevelInfo, "pending")
+			}
+			if tt.opts != nil {
+				if err := client.Info("batched"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			if err := client.Close(context.Background()); err != nil {
+				t.Fatalf("Close() = %v", err)
+			}
+
+			want := 5
+			if tt.opts != nil {
+				want = 6
+			}
+			if logs := server.LoggedPayloads(); len(logs) != want {
+				t.Errorf("logged %d entries before Close returned, want %d", len(logs), want)
+			}
+		})
+	}
+}
+
+func TestLogAfterClose(t *testing.T) {
+	server := confishtest.NewServer()
+	defer server.Close()
+
+	var recorder errorRecorder
+	client, err := server.NewClient(
+		confish.WithBatching(confish.BatchConfig{}),
+		confish.WithErrorHandler(recorder.handle),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := client.Close(context.Background()); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+	if err := client.Close(context.Background()); err != nil {
+		t.Errorf("second Close() = %v", err)
+	}
+
+	if err := client.Info("late"); !errors.Is(err, confish.ErrClientClosed) {
+		t.Errorf("Info() after Close = %v, want ErrClientClosed", err)
+	}
+	if err := client.LogMany([]confish.LogPayload{{Level: confish.LogLevelInfo, Message: "late"}}); !errors.Is(err, confish.ErrClientClosed) {
+		t.Errorf("LogMany() after Close = %v, want ErrClientClosed", err)
+	}
+	client.LogAsync(confish.LogLevelInfo, "late")
+	if errs := recorder.all(); len(errs) != 1 || !errors.Is(errs[0], confish.ErrClientClosed) {
+		t.Errorf("LogAsync() after Close reported %v, want ErrClientClosed", errs)
+	}
+
+	if logs := server.LoggedPayloads(); len(logs) != 0 {
+		t.Errorf("logged payloads after Close = %+v, want none", logs)
+	}
+}
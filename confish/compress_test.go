@@ -0,0 +1,72 @@
+package confish
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecompressResponse(t *testing.T) {
+	compressed, err := gzipBytes([]byte(`{"enabled":true}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name          string
+		method        string
+		status        int
+		body          string
+		contentLength int64
+		want          string
+	}{
+		{"gzip body", http.MethodGet, http.StatusOK, string(compressed), int64(len(compressed)), `{"enabled":true}`},
+		{"gzip body of unknown length", http.MethodGet, http.StatusOK, string(compressed), -1, `{"enabled":true}`},
+		{"no content", http.MethodPut, http.StatusNoContent, "", -1, ""},
+		{"not modified", http.MethodGet, http.StatusNotModified, "", -1, ""},
+		{"HEAD", http.MethodHead, http.StatusOK, "", int64(len(compressed)), ""},
+		{"empty body", http.MethodGet, http.StatusOK, "", 0, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{
+				StatusCode:    tt.status,
+				Header:        http.Header{"Content-Encoding": {"gzip"}},
+				Body:          io.NopCloser(strings.NewReader(tt.body)),
+				ContentLength: tt.contentLength,
+				Request:       httptest.NewRequest(tt.method, "/c/flags", nil),
+			}
+			if err := decompressResponse(resp); err != nil {
+				t.Fatalf("decompressResponse() error = %v", err)
+			}
+
+			body, err := io.ReadAll(resp.Body)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(body) != tt.want {
+				t.Errorf("body = %q, want %q", body, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigExistsWithGzipHeader(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Encoding", "gzip")
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client, err := NewClientWithOptions(server.URL, "app", "secret", WithCompression())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if exists, err := client.ConfigExists("flags"); err != nil || !exists {
+		t.Errorf("ConfigExists() = %v, %v, want true", exists, err)
+	}
+}
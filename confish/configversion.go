@@ -0,0 +1,41 @@
+package confish
+
+import (
+	"context"
+	"net/http"
+	"strings"
+)
+
+// VersionHeader is the response header carrying the revision of a config
+const VersionHeader = "X-Config-Version"
+
+// GetConfigVersioned is like GetConfig but also returns the version of the
+// config. The version is the config's revision as reported by the server in
+// the X-Config-Version header, which changes on every save. Servers that
+// don't send it yield the ETag instead, without its W/ prefix and quotes.
+// Two reads returning the same version saw the same config, so the version
+// can be used to detect stale reads in read-modify-write flows. It is empty
+// when the server sent neither header or the config came from the disk cache.
+func (c *Client) GetConfigVersioned(configID string, result interface{}) (version string, err error) {
+	return c.GetConfigVersionedContext(context.Background(), configID, result)
+}
+
+// GetConfigVersionedContext is like GetConfigVersioned but aborts the request when ctx is cancelled or its deadline passes
+func (c *Client) GetConfigVersionedContext(ctx context.Context, configID string, result interface{}) (version string, err error) {
+	meta, err := c.GetConfigWithMetaContext(ctx, configID, result)
+	if err != nil {
+		return "", err
+	}
+
+	return meta.Version, nil
+}
+
+// configVersion reads the config version from response headers, falling back
+// to etag
+func configVersion(h http.Header, etag string) string {
+	if v := h.Get(VersionHeader); v != "" {
+		return v
+	}
+
+	return strings.Trim(strings.TrimPrefix(etag, "W/"), `"`)
+}
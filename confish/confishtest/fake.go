@@ -0,0 +1,160 @@
+// Package confishtest provides helpers for testing code that uses the confish client.
+package confishtest
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/bravilogy/confish-go/confish"
+)
+
+// FakeClient is an in-memory confish.ConfishClient. It serves configs set with
+// SetConfig and records every log call instead of sending it. It is safe for
+// concurrent use.
+type FakeClient struct {
+	mu      sync.Mutex
+	configs map[string]json.RawMessage
+	logs    []confish.LogPayload
+}
+
+var _ confish.ConfishClient = (*FakeClient)(nil)
+
+// NewFakeClient creates an empty FakeClient
+func NewFakeClient() *FakeClient {
+	return &FakeClient{configs: make(map[string]json.RawMessage)}
+}
+
+// SetConfig stores value, marshaled to JSON, as the config with the given ID
+func (f *FakeClient) SetConfig(configID string, value interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.configs[configID] = raw
+	return nil
+}
+
+// Logs returns the payloads of all log calls made so far
+func (f *FakeClient) Logs() []confish.LogPayload {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return append([]confish.LogPayload(nil), f.logs...)
+}
+
+// Reset forgets stored configs and recorded logs
+func (f *FakeClient) Reset() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.configs = make(map[string]json.RawMessage)
+	f.logs = nil
+}
+
+// GetConfig unmarshals the stored config into result. Unknown IDs yield an
+// error matching confish.ErrConfigNotFound.
+func (f *FakeClient) GetConfig(configID string, result interface{}) error {
+	return f.GetConfigContext(context.Background(), configID, result)
+}
+
+// GetConfigContext is like GetConfig but fails if ctx is already done
+func (f *FakeClient) GetConfigContext(ctx context.Context, configID string, result interface{}) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("failed to fetch config: %w", err)
+	}
+
+	f.mu.Lock()
+	raw, ok := f.configs[configID]
+	f.mu.Unlock()
+
+	if !ok {
+		return &confish.ConfigError{ConfigID: configID, Err: confish.ErrConfigNotFound}
+	}
+
+	if err := json.Unmarshal(raw, result); err != nil {
+		return fmt.Errorf("failed to unmarshal config: %w", err)
+	}
+
+	return nil
+}
+
+// Log records a log message
+func (f *FakeClient) Log(level confish.LogLevel, message string) error {
+	return f.LogFields(level, message, nil)
+}
+
+// LogContext records a log message, failing if ctx is already done
+func (f *FakeClient) LogContext(ctx context.Context, level confish.LogLevel, message string) error {
+	if err := ctx.Err(); err != nil {
+		return fmt.Errorf("failed to send log: %w", err)
+	}
+	return f.LogFields(level, message, nil)
+}
+
+// LogFields records a log message with structured fields
+func (f *FakeClient) LogFields(level confish.LogLevel, message string, fields map[string]interface{}) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.logs = append(f.logs, confish.LogPayload{Level: level, Message: message, Fields: fields})
+	return nil
+}
+
+// Debug records a debug message
+func (f *FakeClient) Debug(message string) error {
+	return f.Log(confish.LogLevelDebug, message)
+}
+
+// Info records an info message
+func (f *FakeClient) Info(message string) error {
+	return f.Log(confish.LogLevelInfo, message)
+}
+
+// Warn records a warning message
+func (f *FakeClient) Warn(message string) error {
+	return f.Log(confish.LogLevelWarn, message)
+}
+
+// Error records an error message
+func (f *FakeClient) Error(message string) error {
+	return f.Log(confish.LogLevelError, message)
+}
+
+// Critical records a critical message
+func (f *FakeClient) Critical(message string) error {
+	return f.Log(confish.LogLevelCritical, message)
+}
+
+// ProcessWebhookPayload handles the payload like the real client does
+func (f *FakeClient) ProcessWebhookPayload(payload confish.WebhookPayload, result interface{}) error {
+	switch payload.Event {
+	case confish.EventConfigurationCreated, confish.EventConfigurationUpdated:
+	case confish.EventConfigurationDeleted:
+		return nil
+	default:
+		return &confish.UnsupportedEventError{Event: payload.Event}
+	}
+
+	values := payload.Configuration.Values
+	if len(payload.Configuration.Patch) > 0 && len(values) == 0 {
+		current, err := json.Marshal(result)
+		if err != nil {
+			return fmt.Errorf("failed to marshal current values: %w", err)
+		}
+		if values, err = confish.ApplyWebhookPatch(current, payload); err != nil {
+			return err
+		}
+	}
+
+	if err := json.Unmarshal(values, result); err != nil {
+		return fmt.Errorf("failed to unmarshal configuration values: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,332 @@
+package confishtest
+
+import (
+	"compress/gzip"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/bravilogy/confish-go/confish"
+)
+
+// Credentials accepted by Server
+const (
+	AppID     = "test-app"
+	AppSecret = "test-secret"
+)
+
+// Server is a running HTTP server that behaves like the Confish API for the
+// app AppID. It serves configs set with SetConfig, lists them, lets
+// UpdateConfig replace them, records every log entry it receives, serves
+// the recent ones to TailLogs and rejects requests without the
+// AppID and AppSecret headers with 401. It is safe for concurrent use. Call
+// Close when done.
+type Server struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	configs map[string]json.RawMessage
+	updated map[string]time.Time
+	logs    []confish.LogEntry
+}
+
+// NewServer starts a Server with no configs
+func NewServer() *Server {
+	s := newServer()
+	s.Start()
+	return s
+}
+
+// NewTLSServer is like NewServer but serves HTTPS with a self-signed
+// certificate, which clients created with NewClient trust. When clientCAs is
+// not nil, the server also requires a client certificate signed by one of
+// them, e.g. to test WithClientCertificate.
+func NewTLSServer(clientCAs *x509.CertPool) *Server {
+	s := newServer()
+	if clientCAs != nil {
+		s.TLS = &tls.Config{ClientAuth: tls.RequireAndVerifyClientCert, ClientCAs: clientCAs}
+	}
+	s.StartTLS()
+	return s
+}
+
+// newServer creates a Server that isn't started yet
+func newServer() *Server {
+	s := &Server{
+		configs: make(map[string]json.RawMessage),
+		updated: make(map[string]time.Time),
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /c/{id}", s.handleConfig)
+	mux.HandleFunc("PUT /c/{id}", s.handleUpdateConfig)
+	mux.HandleFunc("GET /a/{app}/configs", s.handleListConfigs)
+	mux.HandleFunc("HEAD /a/{app}/log", s.handlePing)
+	mux.HandleFunc("GET /a/{app}/log", s.handleTailLogs)
+	mux.HandleFunc("POST /a/{app}/log", s.handleLog)
+	mux.HandleFunc("POST /a/{app}/log/{stream}", s.handleLog)
+	mux.HandleFunc("POST /a/{app}/log/stream", s.handleLogStream)
+	mux.HandleFunc("POST /a/{app}/log/batch", s.handleLogBatch)
+
+	s.Server = httptest.NewUnstartedServer(s.authenticate(mux))
+	return s
+}
+
+// NewClient creates a client for the server with the given options. For a
+// TLS server the client trusts the server's certificate, unless opts set
+// another TLSConfig.
+func (s *Server) NewClient(opts ...confish.Option) (*confish.Client, error) {
+	if cert := s.Certificate(); cert != nil {
+		roots := x509.NewCertPool()
+		roots.AddCert(cert)
+		opts = append([]confish.Option{confish.WithTLSConfig(&tls.Config{RootCAs: roots})}, opts...)
+	}
+	return confish.NewClientWithOptions(s.URL, AppID, AppSecret, opts...)
+}
+
+// SetConfig stores value, marshaled to JSON, as the config with the given ID
+func (s *Server) SetConfig(configID string, value interface{}) error {
+	raw, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.configs[configID] = raw
+	s.updated[configID] = time.Now().UTC()
+	return nil
+}
+
+// LoggedPayloads returns every log entry received so far, including those
+// sent in batches, to named streams or over a StreamLogger
+func (s *Server) LoggedPayloads() []confish.LogPayload {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	payloads := make([]confish.LogPayload, len(s.logs))
+	for i, entry := range s.logs {
+		payloads[i] = confish.LogPayload{Level: entry.Level, Message: entry.Message, Fields: entry.Fields}
+	}
+	return payloads
+}
+
+// record stores received log payloads. s.mu must be held.
+func (s *Server) record(payloads ...confish.LogPayload) {
+	now := time.Now().UTC()
+	for _, p := range payloads {
+		s.logs = append(s.logs, confish.LogEntry{Level: p.Level, Message: p.Message, Timestamp: now, Fields: p.Fields})
+	}
+}
+
+// authenticate rejects requests that don't carry the server's credentials
+func (s *Server) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("App-ID") != AppID || r.Header.Get("App-Secret") != AppSecret {
+			http.Error(w, `{"error":"unauthorized"}`, http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// checkApp rejects requests for an app other than AppID
+func checkApp(w http.ResponseWriter, r *http.Request) bool {
+	if r.PathValue("app") != AppID {
+		http.Error(w, `{"error":"forbidden"}`, http.StatusForbidden)
+		return false
+	}
+	return true
+}
+
+func (s *Server) handleConfig(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	raw, ok := s.configs[r.PathValue("id")]
+	s.mu.Unlock()
+
+	if !ok {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(raw)
+}
+
+func (s *Server) handleUpdateConfig(w http.ResponseWriter, r *http.Request) {
+	var raw json.RawMessage
+	if err := decodeBody(r, &raw); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	id := r.PathValue("id")
+	if _, ok := s.configs[id]; !ok {
+		http.Error(w, `{"error":"not found"}`, http.StatusNotFound)
+		return
+	}
+	s.configs[id] = raw
+	s.updated[id] = time.Now().UTC()
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// handleListConfigs answers with every config in a single page, sorted by
+// ID. Configs are named after their ID.
+func (s *Server) handleListConfigs(w http.ResponseWriter, r *http.Request) {
+	if !checkApp(w, r) {
+		return
+	}
+
+	s.mu.Lock()
+	configs := make([]confish.ConfigSummary, 0, len(s.configs))
+	for id := range s.configs {
+		configs = append(configs, confish.ConfigSummary{ID: id, Name: id, UpdatedAt: s.updated[id]})
+	}
+	s.mu.Unlock()
+
+	sort.Slice(configs, func(i, j int) bool { return configs[i].ID < configs[j].ID })
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"configs": configs})
+}
+
+func (s *Server) handlePing(w http.ResponseWriter, r *http.Request) {
+	if checkApp(w, r) {
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func (s *Server) handleLog(w http.ResponseWriter, r *http.Request) {
+	if !checkApp(w, r) {
+		return
+	}
+
+	var payload confish.LogPayload
+	if err := decodeBody(r, &payload); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.record(payload)
+	s.mu.Unlock()
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+// handleLogStream records each entry of an NDJSON body as it arrives
+func (s *Server) handleLogStream(w http.ResponseWriter, r *http.Request) {
+	if !checkApp(w, r) {
+		return
+	}
+
+	dec := json.NewDecoder(r.Body)
+	for {
+		var payload confish.LogPayload
+		err := dec.Decode(&payload)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid JSON body: %v", err), http.StatusBadRequest)
+			return
+		}
+
+		s.mu.Lock()
+		s.record(payload)
+		s.mu.Unlock()
+	}
+
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) handleLogBatch(w http.ResponseWriter, r *http.Request) {
+	if !checkApp(w, r) {
+		return
+	}
+
+	var payloads []confish.LogPayload
+	if err := decodeBody(r, &payloads); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	s.mu.Lock()
+	s.record(payloads...)
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]int{"accepted": len(payloads)})
+}
+
+// handleTailLogs answers with the most recent entries, oldest first, honouring
+// the limit, since and until query parameters
+func (s *Server) handleTailLogs(w http.ResponseWriter, r *http.Request) {
+	if !checkApp(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+	limit, err := strconv.Atoi(query.Get("limit"))
+	if err != nil || limit <= 0 {
+		http.Error(w, `{"error":"invalid limit"}`, http.StatusBadRequest)
+		return
+	}
+
+	var since, until time.Time
+	for name, t := range map[string]*time.Time{"since": &since, "until": &until} {
+		if v := query.Get(name); v != "" {
+			if *t, err = time.Parse(time.RFC3339Nano, v); err != nil {
+				http.Error(w, fmt.Sprintf(`{"error":"invalid %s"}`, name), http.StatusBadRequest)
+				return
+			}
+		}
+	}
+
+	s.mu.Lock()
+	entries := make([]confish.LogEntry, 0, len(s.logs))
+	for _, entry := range s.logs {
+		if (since.IsZero() || !entry.Timestamp.Before(since)) && (until.IsZero() || entry.Timestamp.Before(until)) {
+			entries = append(entries, entry)
+		}
+	}
+	s.mu.Unlock()
+
+	if len(entries) > limit {
+		entries = entries[len(entries)-limit:]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{"logs": entries})
+}
+
+// decodeBody unmarshals a JSON request body, gunzipping it if needed
+func decodeBody(r *http.Request, v interface{}) error {
+	var body io.Reader = r.Body
+	if r.Header.Get("Content-Encoding") == "gzip" {
+		zr, err := gzip.NewReader(r.Body)
+		if err != nil {
+			return fmt.Errorf("invalid gzip body: %w", err)
+		}
+		defer zr.Close()
+		body = zr
+	}
+
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		return fmt.Errorf("invalid JSON body: %w", err)
+	}
+	return nil
+}
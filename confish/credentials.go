@@ -0,0 +1,80 @@
+package confish
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// appCredentials is the app ID and secret sent with every request
+type appCredentials struct {
+	id     string
+	secret string
+}
+
+// SetCredentials replaces the app ID and secret used by the client, e.g. when
+// the secret is rotated. Requests started afterwards use the new values;
+// requests already sent are unaffected. Webhook signatures are verified with
+// the new secret from then on.
+func (c *Client) SetCredentials(appID, appSecret string) error {
+	if appID == "" {
+		return errors.New("appID cannot be empty")
+	}
+
+	if appSecret == "" {
+		return errors.New("appSecret cannot be empty")
+	}
+
+	c.credsMu.Lock()
+	defer c.credsMu.Unlock()
+
+	c.creds = appCredentials{id: appID, secret: appSecret}
+	return nil
+}
+
+// credentials returns the current app ID and secret
+func (c *Client) credentials() (appID, appSecret string) {
+	c.credsMu.RLock()
+	defer c.credsMu.RUnlock()
+
+	return c.creds.id, c.creds.secret
+}
+
+// appID returns the current app ID
+func (c *Client) appID() string {
+	id, _ := c.credentials()
+	return id
+}
+
+// ReloadAppSecret re-reads AppSecretFile and uses its contents as the app
+// secret from then on, e.g. after the mounted secret was rotated. It fails
+// when no AppSecretFile is configured or the file is missing or empty, in
+// which case the current secret is kept.
+func (c *Client) ReloadAppSecret() error {
+	if c.cfg.AppSecretFile == "" {
+		return errors.New("no AppSecretFile configured")
+	}
+
+	secret, err := readSecretFile(c.cfg.AppSecretFile)
+	if err != nil {
+		return err
+	}
+
+	return c.SetCredentials(c.appID(), secret)
+}
+
+// readSecretFile returns the trimmed contents of a secret file
+func readSecretFile(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to read secret file: %w", err)
+	}
+
+	secret := strings.TrimSpace(string(data))
+	if secret == "" {
+		return "", fmt.Errorf("secret file %s is empty", path)
+	}
+
+	return secret, nil
+}
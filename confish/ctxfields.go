@@ -0,0 +1,22 @@
+package confish
+
+import "context"
+
+type fieldsKey struct{}
+
+// ContextWithFields returns a context carrying fields for the log calls made
+// with it, such as a trace or user ID stashed by middleware. Fields already
+// in ctx are kept unless fields has the same keys.
+//
+// Context-aware log methods merge the fields in this order, later ones
+// winning on key collisions: the client's default fields, the context
+// fields, then the fields passed to the call.
+func ContextWithFields(ctx context.Context, fields map[string]interface{}) context.Context {
+	return context.WithValue(ctx, fieldsKey{}, mergeFields(FieldsFromContext(ctx), fields))
+}
+
+// FieldsFromContext returns the fields stored in ctx by ContextWithFields, or nil
+func FieldsFromContext(ctx context.Context) map[string]interface{} {
+	fields, _ := ctx.Value(fieldsKey{}).(map[string]interface{})
+	return fields
+}
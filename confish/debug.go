@@ -0,0 +1,24 @@
+package confish
+
+import (
+	"net/http"
+	"time"
+)
+
+// debugExchange reports one HTTP attempt to the DebugLogger, if any. Only
+// the method, URL, status and duration are logged.
+func (c *Client) debugExchange(req *http.Request, attempt int, resp *http.Response, err error, d time.Duration) {
+	logf := c.cfg.DebugLogger
+	if logf == nil {
+		return
+	}
+
+	url := req.URL.Redacted()
+	d = d.Round(time.Microsecond)
+
+	if err != nil {
+		logf("confish: %s %s attempt=%d error=%q duration=%s", req.Method, url, attempt+1, err.Error(), d)
+		return
+	}
+	logf("confish: %s %s attempt=%d status=%d duration=%s", req.Method, url, attempt+1, resp.StatusCode, d)
+}
@@ -0,0 +1,191 @@
+package confish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"reflect"
+	"strings"
+)
+
+// UnmarshalFunc decodes raw config bytes into v, like json.Unmarshal or yaml.Unmarshal
+type UnmarshalFunc func(data []byte, v interface{}) error
+
+// GetConfigWith is like GetConfig but decodes the config with unmarshal
+// instead of choosing a decoder from the response Content-Type
+func (c *Client) GetConfigWith(configID string, result interface{}, unmarshal UnmarshalFunc) error {
+	return c.GetConfigWithContext(context.Background(), configID, result, unmarshal)
+}
+
+// GetConfigWithContext is like GetConfigWith but aborts the request when ctx is cancelled or its deadline passes
+func (c *Client) GetConfigWithContext(ctx context.Context, configID string, result interface{}, unmarshal UnmarshalFunc) error {
+	if err := checkTarget(result); err != nil {
+		return err
+	}
+
+	body, _, err := c.fetchConfig(ctx, configID)
+	if err != nil {
+		return err
+	}
+
+	return c.unmarshalConfig(configID, body, unmarshal, result)
+}
+
+// decoderFor returns the configured decoder for a Content-Type header value,
+// defaulting to JSON
+func (c *Client) decoderFor(contentType string) UnmarshalFunc {
+	if len(c.cfg.Decoders) > 0 && contentType != "" {
+		mediaType, _, err := mime.ParseMediaType(contentType)
+		if err == nil {
+			for name, unmarshal := range c.cfg.Decoders {
+				if strings.EqualFold(name, mediaType) {
+					return unmarshal
+				}
+			}
+		}
+	}
+
+	return c.unmarshalJSON
+}
+
+// unmarshalJSON decodes a JSON config with the configured Unmarshal, or else
+// encoding/json honouring UseNumber and StrictDecode
+func (c *Client) unmarshalJSON(data []byte, v interface{}) error {
+	if c.cfg.Unmarshal != nil {
+		return c.cfg.Unmarshal(data, v)
+	}
+
+	if !c.cfg.UseNumber && !c.cfg.StrictDecode {
+		return json.Unmarshal(data, v)
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(data))
+	if c.cfg.UseNumber {
+		dec.UseNumber()
+	}
+	if c.cfg.StrictDecode {
+		dec.DisallowUnknownFields()
+	}
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+
+	// Match json.Unmarshal, which rejects anything after the value
+	if _, err := dec.Token(); err != io.EOF {
+		return errors.New("invalid data after top-level value")
+	}
+
+	return nil
+}
+
+// decodeValues decodes configuration values received in a webhook into
+// result like a JSON config from the API: it unmarshals them honouring
+// Unmarshal, UseNumber and StrictDecode, applies default tags and validates
+// the result. A nil client unmarshals with encoding/json.
+func (c *Client) decodeValues(values json.RawMessage, result interface{}) error {
+	unmarshal := json.Unmarshal
+	if c != nil {
+		unmarshal = c.unmarshalJSON
+	}
+
+	if err := unmarshal(values, result); err != nil {
+		return fmt.Errorf("failed to unmarshal configuration values: %w", err)
+	}
+
+	if err := applyDefaults(result); err != nil {
+		return err
+	}
+
+	return validateConfig(result)
+}
+
+// decodeConfig unmarshals the raw body of configID served as contentType into
+// result and validates it
+func (c *Client) decodeConfig(configID string, body []byte, contentType string, result interface{}) error {
+	return c.unmarshalConfig(configID, body, c.decoderFor(contentType), result)
+}
+
+// marshal serializes a log payload with the configured Marshal, defaulting
+// to json.Marshal
+func (c *Client) marshal(v interface{}) ([]byte, error) {
+	if c.cfg.Marshal != nil {
+		return c.cfg.Marshal(v)
+	}
+	return json.Marshal(v)
+}
+
+// ErrEmptyConfig is returned when the API serves a config with an empty body
+var ErrEmptyConfig = errors.New("confish: empty config")
+
+// unmarshalConfig decodes body with unmarshal into result, applies default
+// tags and validates it. An empty body is ErrEmptyConfig, or the zero value
+// with AllowEmptyConfig.
+func (c *Client) unmarshalConfig(configID string, body []byte, unmarshal UnmarshalFunc, result interface{}) error {
+	if len(bytes.TrimSpace(body)) == 0 {
+		if !c.cfg.AllowEmptyConfig {
+			return c.configError(configID, ErrEmptyConfig)
+		}
+		v := reflect.ValueOf(result).Elem()
+		v.Set(reflect.Zero(v.Type()))
+	} else if err := unmarshal(body, result); err != nil {
+		return c.configError(configID, fmt.Errorf("failed to unmarshal config: %w", err))
+	}
+
+	if err := applyDefaults(result); err != nil {
+		return c.configError(configID, err)
+	}
+
+	if err := validateConfig(result); err != nil {
+		return c.configError(configID, err)
+	}
+
+	return nil
+}
+
+// ErrNonPointerTarget is returned when the result passed to a GetConfig method
+// is not a non-nil pointer, so nothing could be decoded into it
+var ErrNonPointerTarget = errors.New("confish: result must be a non-nil pointer")
+
+// checkTarget fails fast, before any request, when result cannot be decoded into
+func checkTarget(result interface{}) error {
+	if result == nil {
+		return fmt.Errorf("%w, got nil", ErrNonPointerTarget)
+	}
+
+	v := reflect.ValueOf(result)
+	if v.Kind() != reflect.Pointer {
+		return fmt.Errorf("%w, got %T", ErrNonPointerTarget, result)
+	}
+	if v.IsNil() {
+		return fmt.Errorf("%w, got nil %T", ErrNonPointerTarget, result)
+	}
+
+	return nil
+}
+
+// Validator is implemented by config types that check their own invariants,
+// such as required fields or value ranges. When the result passed to a
+// GetConfig method implements it, Validate is called after a successful
+// unmarshal and its error is returned wrapped. It is not called when
+// unmarshaling fails.
+type Validator interface {
+	Validate() error
+}
+
+// validateConfig runs result's Validate method, if it has one
+func validateConfig(result interface{}) error {
+	v, ok := result.(Validator)
+	if !ok {
+		return nil
+	}
+
+	if err := v.Validate(); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+
+	return nil
+}
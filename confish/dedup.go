@@ -0,0 +1,115 @@
+package confish
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// DedupCountField is the field holding the number of collapsed repeats in the
+// summary entry sent by log deduplication
+const DedupCountField = "count"
+
+// logDeduper collapses identical consecutive log entries, see
+// ConfishConfig.LogDedupWindow. A nil deduper lets every entry through.
+type logDeduper struct {
+	client *Client
+	window time.Duration
+
+	mu      sync.Mutex
+	first   *LogPayload // the entry that opened the current window
+	repeats int
+	stop    chan struct{} // closed to cancel the current window's timer
+}
+
+func newLogDeduper(c *Client, window time.Duration) *logDeduper {
+	if window <= 0 {
+		return nil
+	}
+	return &logDeduper{client: c, window: window}
+}
+
+// check reports whether payload should be sent, and returns the summary of
+// the previous window when payload closes it
+func (d *logDeduper) check(payload LogPayload) (send bool, summary *LogPayload) {
+	if d == nil {
+		return true, nil
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.first != nil && d.first.Level == payload.Level && d.first.Message == payload.Message {
+		d.repeats++
+		return false, nil
+	}
+
+	summary = d.closeWindow()
+	d.first = &payload
+	d.stop = make(chan struct{})
+	go d.expire(d.stop)
+
+	return true, summary
+}
+
+// expire closes the window once it has lasted d.window, unless stop is
+// closed first, and sends its summary
+func (d *logDeduper) expire(stop chan struct{}) {
+	timer := d.client.clock.NewTimer(d.window)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C():
+	case <-stop:
+		return
+	}
+
+	d.mu.Lock()
+	if d.stop != stop {
+		d.mu.Unlock()
+		return
+	}
+	summary := d.closeWindow()
+	d.mu.Unlock()
+
+	if summary != nil {
+		if err := d.client.deliverPayload(context.Background(), *summary); err != nil {
+			d.client.handleError(err)
+		}
+	}
+}
+
+// closeWindow ends the current window and returns its summary entry, or nil
+// when nothing was collapsed. d.mu must be held.
+func (d *logDeduper) closeWindow() *LogPayload {
+	if d.first == nil {
+		return nil
+	}
+
+	close(d.stop)
+	first, repeats := *d.first, d.repeats
+	d.first, d.repeats, d.stop = nil, 0, nil
+
+	if repeats == 0 {
+		return nil
+	}
+
+	first.Fields = mergeFields(first.Fields, map[string]interface{}{DedupCountField: repeats})
+	return &first
+}
+
+// flush ends the current window early and sends its summary
+func (d *logDeduper) flush(ctx context.Context) error {
+	if d == nil {
+		return nil
+	}
+
+	d.mu.Lock()
+	summary := d.closeWindow()
+	d.mu.Unlock()
+
+	if summary == nil {
+		return nil
+	}
+	return d.client.deliverPayload(ctx, *summary)
+}
@@ -0,0 +1,104 @@
+package confish
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// logServer sends every log entry it receives on the returned channel
+func logServer(t *testing.T) (*httptest.Server, <-chan LogPayload) {
+	t.Helper()
+
+	logs := make(chan LogPayload, 16)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var payload LogPayload
+		if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+			t.Errorf("log body: %v", err)
+		}
+		logs <- payload
+		w.WriteHeader(http.StatusCreated)
+	}))
+	t.Cleanup(server.Close)
+	return server, logs
+}
+
+// received returns the messages of the entries sent so far, with the count
+// of summaries in brackets
+func received(logs <-chan LogPayload) []string {
+	var got []string
+	for {
+		select {
+		case p := <-logs:
+			if count, ok := p.Fields[DedupCountField]; ok {
+				got = append(got, fmt.Sprintf("%s[%v]", p.Message, count))
+			} else {
+				got = append(got, p.Message)
+			}
+		default:
+			return got
+		}
+	}
+}
+
+func TestLogDedupWindow(t *testing.T) {
+	server, logs := logServer(t)
+	clk := newFakeClock()
+	client, err := NewClientWithOptions(server.URL, "app", "secret",
+		func(cfg *ConfishConfig) { cfg.LogDedupWindow = time.Minute },
+		withClock(clk),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	info := func(message string) {
+		t.Helper()
+		if err := client.Info(message); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	info("a")
+	if d := clk.waitTimer(t); d != time.Minute {
+		t.Fatalf("window = %v, want 1m", d)
+	}
+	info("a")
+	info("a")
+	info("a")
+	if got := received(logs); fmt.Sprint(got) != "[a]" {
+		t.Fatalf("sent within the window = %q, want only the first entry", got)
+	}
+
+	// The window ends and its summary carries the number of repeats
+	clk.Advance(time.Minute)
+	select {
+	case p := <-logs:
+		if p.Message != "a" || p.Fields[DedupCountField] != float64(3) {
+			t.Errorf("summary = %+v, want a with count 3", p)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no summary was sent at the end of the window")
+	}
+
+	// A different entry closes the window early
+	info("b")
+	clk.waitTimer(t)
+	info("b")
+	info("c")
+	clk.waitTimer(t)
+	if got := received(logs); fmt.Sprint(got) != "[b b[1] c]" {
+		t.Errorf("sent = %q, want b, its summary, then c", got)
+	}
+
+	// A window without repeats ends without a summary
+	clk.Advance(time.Minute)
+	info("d")
+	clk.waitTimer(t)
+	if got := received(logs); fmt.Sprint(got) != "[d]" {
+		t.Errorf("sent after a window without repeats = %q, want only d", got)
+	}
+}
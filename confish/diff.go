@@ -0,0 +1,102 @@
+package confish
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// DiffConfig returns the JSON Pointers (RFC 6901) of the values that differ
+// between two versions of a config, sorted, e.g. ["/limits/max", "/name"].
+// Added and removed object keys are reported as well as modified values;
+// arrays are compared element by element, so an element added at the end
+// is reported by its index. When both values differ in type, such as an
+// object replaced by a string, only their common path is reported, and ""
+// stands for the whole config. Numbers are compared by value, so 1 and 1.0
+// are equal. An empty input is treated as null. No paths and a nil error
+// mean the versions are equivalent.
+func DiffConfig(previous, current json.RawMessage) ([]string, error) {
+	var values [2]interface{}
+	for i, raw := range []json.RawMessage{previous, current} {
+		if !hasJSON(raw) {
+			continue
+		}
+		if err := unmarshalNumbers(raw, &values[i]); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal config: %w", err)
+		}
+	}
+
+	var paths []string
+	diffValues("", values[0], values[1], &paths)
+	sort.Strings(paths)
+
+	return paths, nil
+}
+
+// diffValues appends to paths the pointers below path where a and b differ
+func diffValues(path string, a, b interface{}, paths *[]string) {
+	switch a := a.(type) {
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+		for key, av := range a {
+			bv, ok := b[key]
+			if !ok {
+				*paths = append(*paths, pointerChild(path, key))
+				continue
+			}
+			diffValues(pointerChild(path, key), av, bv, paths)
+		}
+		for key := range b {
+			if _, ok := a[key]; !ok {
+				*paths = append(*paths, pointerChild(path, key))
+			}
+		}
+		return
+	case []interface{}:
+		b, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+		for i := 0; i < len(a) || i < len(b); i++ {
+			child := path + "/" + strconv.Itoa(i)
+			if i >= len(a) || i >= len(b) {
+				*paths = append(*paths, child)
+				continue
+			}
+			diffValues(child, a[i], b[i], paths)
+		}
+		return
+	case json.Number:
+		if b, ok := b.(json.Number); ok && numbersEqual(a, b) {
+			return
+		}
+	default:
+		if a == b {
+			return
+		}
+	}
+
+	*paths = append(*paths, path)
+}
+
+// pointerChild returns the pointer to key in the object at path
+func pointerChild(path, key string) string {
+	return path + "/" + strings.ReplaceAll(strings.ReplaceAll(key, "~", "~0"), "/", "~1")
+}
+
+// numbersEqual compares two JSON numbers exactly by value
+func numbersEqual(a, b json.Number) bool {
+	if a == b {
+		return true
+	}
+
+	x, okA := new(big.Rat).SetString(a.String())
+	y, okB := new(big.Rat).SetString(b.String())
+	return okA && okB && x.Cmp(y) == 0
+}
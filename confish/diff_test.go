@@ -0,0 +1,54 @@
+package confish_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/bravilogy/confish-go/confish"
+)
+
+func TestDiffConfig(t *testing.T) {
+	tests := []struct {
+		name              string
+		previous, current string
+		want              []string
+	}{
+		{"equal", `{"a":1,"b":[1,2]}`, `{"b":[1,2],"a":1}`, nil},
+		{"nested change", `{"limits":{"max":1,"min":0}}`, `{"limits":{"max":2,"min":0}}`, []string{"/limits/max"}},
+		{"array grown", `{"hosts":["a","b"]}`, `{"hosts":["a","b","c"]}`, []string{"/hosts/2"}},
+		{"array shrunk", `{"hosts":["a","b","c"]}`, `{"hosts":["a"]}`, []string{"/hosts/1", "/hosts/2"}},
+		{"array element changed", `[{"id":1},{"id":2}]`, `[{"id":1},{"id":3}]`, []string{"/1/id"}},
+		{"key added", `{"a":1}`, `{"a":1,"b":2}`, []string{"/b"}},
+		{"key removed", `{"a":1,"b":2}`, `{"b":2}`, []string{"/a"}},
+		{"escaped keys", `{"a/b":1,"m~n":1}`, `{"a/b":2,"m~n":2}`, []string{"/a~1b", "/m~0n"}},
+		{"type change", `{"a":{"b":1}}`, `{"a":"b"}`, []string{"/a"}},
+		{"root type change", `{"a":1}`, `[1]`, []string{""}},
+		{"null to value", `{"a":null}`, `{"a":0}`, []string{"/a"}},
+		{"empty previous", ``, `{"a":1}`, []string{""}},
+		{"integer and float", `{"n":1,"m":[2.50]}`, `{"n":1.0,"m":[2.5]}`, nil},
+		{"exponent", `{"n":100}`, `{"n":1e2}`, nil},
+		{"large integers", `{"n":9007199254740993}`, `{"n":9007199254740992}`, []string{"/n"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := confish.DiffConfig(json.RawMessage(tt.previous), json.RawMessage(tt.current))
+			if err != nil {
+				t.Fatalf("DiffConfig() error = %v", err)
+			}
+			if len(got) == 0 && len(tt.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffConfig() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiffConfigInvalidJSON(t *testing.T) {
+	if _, err := confish.DiffConfig(json.RawMessage(`{"a":1}`), json.RawMessage(`{"a":`)); err == nil {
+		t.Error("DiffConfig() of invalid JSON succeeded")
+	}
+}
@@ -0,0 +1,78 @@
+package confish
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// diskCachePath returns the file a config is cached in. The ID is escaped so
+// it cannot point outside the cache directory.
+func (c *Client) diskCachePath(configID string) string {
+	return filepath.Join(c.cfg.DiskCacheDir, url.PathEscape(configID)+".json")
+}
+
+// diskCacheTypePath returns the file holding the Content-Type of a cached
+// config, next to its body
+func (c *Client) diskCacheTypePath(configID string) string {
+	return filepath.Join(c.cfg.DiskCacheDir, url.PathEscape(configID)+".content-type")
+}
+
+// writeDiskCache replaces the cached copy of a config and the Content-Type
+// it was served as
+func (c *Client) writeDiskCache(configID string, body []byte, contentType string) error {
+	if err := os.MkdirAll(c.cfg.DiskCacheDir, 0o700); err != nil {
+		return fmt.Errorf("failed to create disk cache directory: %w", err)
+	}
+
+	if err := c.writeDiskCacheFile(c.diskCacheTypePath(configID), []byte(contentType)); err != nil {
+		return err
+	}
+
+	return c.writeDiskCacheFile(c.diskCachePath(configID), body)
+}
+
+// writeDiskCacheFile atomically replaces path by writing to a temporary file
+// and renaming it
+func (c *Client) writeDiskCacheFile(path string, data []byte) error {
+	tmp, err := os.CreateTemp(c.cfg.DiskCacheDir, ".confish-*.tmp")
+	if err != nil {
+		return fmt.Errorf("failed to create disk cache file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write disk cache file: %w", err)
+	}
+
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write disk cache file: %w", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to replace disk cache file: %w", err)
+	}
+
+	return nil
+}
+
+// readDiskCache returns the last cached copy of a config and its
+// Content-Type, which is empty for copies cached without one
+func (c *Client) readDiskCache(configID string) ([]byte, string, error) {
+	body, err := os.ReadFile(c.diskCachePath(configID))
+	if err != nil {
+		return nil, "", err
+	}
+
+	contentType, err := os.ReadFile(c.diskCacheTypePath(configID))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, "", err
+	}
+
+	return body, strings.TrimSpace(string(contentType)), nil
+}
@@ -0,0 +1,60 @@
+package confish_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/bravilogy/confish-go/confish"
+)
+
+func TestDiskCacheKeepsContentType(t *testing.T) {
+	var down atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Header().Set("Content-Type", "text/x-lines; charset=utf-8")
+		w.Write([]byte("alpha\nbeta"))
+	}))
+	defer server.Close()
+
+	decodeLines := func(data []byte, v interface{}) error {
+		*v.(*[]string) = strings.Split(string(data), "\n")
+		return nil
+	}
+	client, err := confish.NewClientWithOptions(server.URL, "app", "secret",
+		confish.WithDiskCache(t.TempDir()),
+		confish.WithRetries(0, 0),
+		func(cfg *confish.ConfishConfig) {
+			cfg.Decoders = map[string]confish.UnmarshalFunc{"text/x-lines": decodeLines}
+		},
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var fresh []string
+	if _, err := client.GetConfigWithMeta("names", &fresh); err != nil {
+		t.Fatal(err)
+	}
+
+	down.Store(true)
+	var cached []string
+	meta, err := client.GetConfigWithMeta("names", &cached)
+	if err != nil {
+		t.Fatalf("GetConfigWithMeta() from disk error = %v", err)
+	}
+	if !meta.FromDisk {
+		t.Error("FromDisk = false, want true")
+	}
+	if want := "text/x-lines; charset=utf-8"; meta.ContentType != want {
+		t.Errorf("ContentType = %q, want %q", meta.ContentType, want)
+	}
+	if len(cached) != 2 || cached[0] != "alpha" || cached[1] != "beta" {
+		t.Errorf("config from disk = %q, want [alpha beta]", cached)
+	}
+}
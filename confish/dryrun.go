@@ -0,0 +1,39 @@
+package confish
+
+import (
+	"fmt"
+	"io"
+	"os"
+)
+
+// writeDryRun writes a log payload, or each payload of a batch, to the dry-run
+// writer as one JSON line per entry
+func (c *Client) writeDryRun(v interface{}) error {
+	entries := []interface{}{v}
+	if batch, ok := v.([]LogPayload); ok {
+		entries = entries[:0]
+		for _, p := range batch {
+			entries = append(entries, p)
+		}
+	}
+
+	var w io.Writer = os.Stderr
+	if c.cfg.DryRunWriter != nil {
+		w = c.cfg.DryRunWriter
+	}
+
+	c.dryRunMu.Lock()
+	defer c.dryRunMu.Unlock()
+
+	for _, entry := range entries {
+		line, err := c.marshal(entry)
+		if err != nil {
+			return fmt.Errorf("failed to marshal dry-run log: %w", err)
+		}
+		if _, err := w.Write(append(line, '\n')); err != nil {
+			return fmt.Errorf("failed to write dry-run log: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,48 @@
+package confish
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// DefaultEnvPrefix is the environment variable prefix used by NewClientFromEnv
+const DefaultEnvPrefix = "CONFISH_"
+
+// NewClientFromEnv creates a client from the CONFISH_URL, CONFISH_APP_ID and
+// CONFISH_APP_SECRET environment variables. CONFISH_APP_SECRET_FILE may be
+// set instead of CONFISH_APP_SECRET. CONFISH_WEBHOOK_PATH is optional.
+func NewClientFromEnv(opts ...Option) (*Client, error) {
+	return NewClientFromEnvPrefix(DefaultEnvPrefix, opts...)
+}
+
+// NewClientFromEnvPrefix is like NewClientFromEnv but reads variables named
+// with the given prefix, e.g. "MYAPP_CONFISH_" reads MYAPP_CONFISH_URL
+func NewClientFromEnvPrefix(prefix string, opts ...Option) (*Client, error) {
+	var missing []string
+	lookup := func(name string) string {
+		value := os.Getenv(prefix + name)
+		if value == "" {
+			missing = append(missing, prefix+name)
+		}
+		return value
+	}
+
+	url := lookup("URL")
+	appID := lookup("APP_ID")
+	var appSecret string
+	if secretFile := os.Getenv(prefix + "APP_SECRET_FILE"); secretFile != "" {
+		opts = append([]Option{WithAppSecretFile(secretFile)}, opts...)
+	} else {
+		appSecret = lookup("APP_SECRET")
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("missing environment variable(s): %s", strings.Join(missing, ", "))
+	}
+
+	if path := os.Getenv(prefix + "WEBHOOK_PATH"); path != "" {
+		opts = append([]Option{WithWebhookPath(path)}, opts...)
+	}
+
+	return NewClientWithOptions(url, appID, appSecret, opts...)
+}
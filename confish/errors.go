@@ -0,0 +1,229 @@
+package confish
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrUnauthorized is matched by errors for 401 responses, usually caused
+	// by a wrong AppID or AppSecret
+	ErrUnauthorized = errors.New("confish: unauthorized")
+	// ErrForbidden is matched by errors for 403 responses
+	ErrForbidden = errors.New("confish: forbidden")
+	// ErrConfigNotFound is matched by errors for config requests that got a
+	// 404 response
+	ErrConfigNotFound = errors.New("confish: config not found")
+	// ErrConflict is matched by errors for 409 and 412 responses, e.g. when
+	// an UpdateConfigIfMatch ETag no longer matches the stored config
+	ErrConflict = errors.New("confish: conflict")
+	// ErrGatewayTimeout is matched by errors for 504 responses, as opposed to
+	// context.DeadlineExceeded for requests aborted by their context or the
+	// client's Timeout
+	ErrGatewayTimeout = errors.New("confish: gateway timeout")
+)
+
+var (
+	// ErrDNS is matched by errors for requests whose host name could not be
+	// resolved
+	ErrDNS = errors.New("confish: DNS lookup failed")
+	// ErrConnectionRefused is matched by errors for requests whose connection
+	// was refused
+	ErrConnectionRefused = errors.New("confish: connection refused")
+	// ErrTLS is matched by errors for requests that failed the TLS handshake,
+	// e.g. because the server certificate could not be verified
+	ErrTLS = errors.New("confish: TLS handshake failed")
+)
+
+// HTTPError is returned when Confish responds with an unexpected status code.
+// Use errors.Is with ErrUnauthorized, ErrForbidden, ErrConfigNotFound,
+// ErrConflict or ErrGatewayTimeout to check for the common cases.
+type HTTPError struct {
+	StatusCode int
+	Body       string
+	// RequestID is the request ID echoed by the server, or the one the client
+	// sent if the server echoed none
+	RequestID string
+
+	sentinel error
+}
+
+func (e *HTTPError) Error() string {
+	if e.RequestID != "" {
+		return fmt.Sprintf("received non-OK response: %d, body: %s, request id: %s", e.StatusCode, e.Body, e.RequestID)
+	}
+	return fmt.Sprintf("received non-OK response: %d, body: %s", e.StatusCode, e.Body)
+}
+
+// Unwrap returns the sentinel error matching the status code, if any
+func (e *HTTPError) Unwrap() error {
+	return e.sentinel
+}
+
+// isSuccess reports whether status is a 2xx code. Every 2xx counts as success,
+// not just the 200 or 201 the API itself sends, because gateways in front of
+// it answer log requests with 202 Accepted when ingesting asynchronously and
+// configs with 203 when served from their cache. The exact code is still
+// available, e.g. in ConfigMeta.StatusCode.
+func isSuccess(status int) bool {
+	return status >= 200 && status <= 299
+}
+
+// newHTTPError builds an HTTPError from resp, consuming up to
+// maxErrorBodyBytes of its body. notFound is the sentinel a 404 should match,
+// which depends on the resource requested.
+func newHTTPError(resp *http.Response, notFound error) *HTTPError {
+	bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	e := &HTTPError{StatusCode: resp.StatusCode, Body: string(bodyBytes), RequestID: resp.Header.Get(RequestIDHeader)}
+	if e.RequestID == "" && resp.Request != nil {
+		e.RequestID = resp.Request.Header.Get(RequestIDHeader)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusUnauthorized:
+		e.sentinel = ErrUnauthorized
+	case http.StatusForbidden:
+		e.sentinel = ErrForbidden
+	case http.StatusNotFound:
+		e.sentinel = notFound
+	case http.StatusConflict, http.StatusPreconditionFailed:
+		e.sentinel = ErrConflict
+	case http.StatusGatewayTimeout:
+		e.sentinel = ErrGatewayTimeout
+	}
+
+	return e
+}
+
+// RateLimitError is returned when Confish responds with 429 Too Many Requests
+// and no retries are left. RetryAfter holds the delay parsed from the
+// Retry-After header, or zero if the header was missing or invalid.
+type RateLimitError struct {
+	RetryAfter time.Duration
+	*HTTPError
+}
+
+func (e *RateLimitError) Error() string {
+	if e.RetryAfter > 0 {
+		return fmt.Sprintf("rate limited by confish, retry after %s, body: %s", e.RetryAfter, e.Body)
+	}
+	return fmt.Sprintf("rate limited by confish, body: %s", e.Body)
+}
+
+// Unwrap returns the underlying HTTPError
+func (e *RateLimitError) Unwrap() error {
+	return e.HTTPError
+}
+
+// ConfigError is returned when fetching or decoding a config fails. It
+// records which config and app the call was for; use errors.As to read them.
+// The underlying error, such as an *HTTPError, is available through Unwrap.
+type ConfigError struct {
+	ConfigID string
+	AppID    string
+	Err      error
+}
+
+func (e *ConfigError) Error() string {
+	return fmt.Sprintf("config %s: %v", e.ConfigID, e.Err)
+}
+
+func (e *ConfigError) Unwrap() error {
+	return e.Err
+}
+
+// authorizeError is returned when the Authorizer or TokenSource fails to
+// authorize a request, which says nothing about the API's availability
+type authorizeError struct {
+	err error
+}
+
+func (e *authorizeError) Error() string {
+	return "failed to authorize request: " + e.err.Error()
+}
+
+func (e *authorizeError) Unwrap() error {
+	return e.err
+}
+
+// configError wraps err with the config and app it occurred for
+func (c *Client) configError(configID string, err error) error {
+	return &ConfigError{ConfigID: configID, AppID: c.appID(), Err: err}
+}
+
+// classifyNetError wraps a transport error so that it matches ErrDNS,
+// ErrConnectionRefused or ErrTLS when it is one of those failures. The
+// original error stays in the chain.
+func classifyNetError(err error) error {
+	var dnsErr *net.DNSError
+	switch {
+	case errors.As(err, &dnsErr):
+		return fmt.Errorf("%w: %w", ErrDNS, err)
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return fmt.Errorf("%w: %w", ErrConnectionRefused, err)
+	case isTLSError(err):
+		return fmt.Errorf("%w: %w", ErrTLS, err)
+	}
+	return err
+}
+
+// isTLSError reports whether err comes from a failed TLS handshake, including
+// alerts sent by the server, such as one asking for a client certificate
+func isTLSError(err error) bool {
+	var (
+		verifyErr    *tls.CertificateVerificationError
+		recordErr    tls.RecordHeaderError
+		alertErr     tls.AlertError
+		authorityErr x509.UnknownAuthorityError
+		hostnameErr  x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+		opErr        *net.OpError
+	)
+	if errors.As(err, &opErr) && opErr.Op == "remote error" {
+		// crypto/tls reports alerts from the peer this way
+		return true
+	}
+	return errors.As(err, &verifyErr) || errors.As(err, &recordErr) || errors.As(err, &alertErr) ||
+		errors.As(err, &authorityErr) || errors.As(err, &hostnameErr) || errors.As(err, &invalidErr)
+}
+
+// IsRetryable reports whether the same call may succeed if tried again later:
+// for 429, 502, 503 and 504 responses, refused or dropped connections,
+// temporary DNS failures, an open circuit breaker and a full log queue. It
+// reports false for cancelled or expired contexts, TLS failures, unknown
+// hosts, other HTTP errors and errors not caused by the request, such as
+// decoding failures.
+func IsRetryable(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	if errors.Is(err, ErrCircuitOpen) || errors.Is(err, ErrLogQueueFull) {
+		return true
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return retryableStatus(httpErr.StatusCode)
+	}
+
+	if errors.Is(err, ErrTLS) {
+		return false
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return dnsErr.IsTemporary || dnsErr.IsTimeout
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,108 @@
+package confish
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestClassifyNetError(t *testing.T) {
+	// transportErr wraps err the way http.Client reports failures
+	transportErr := func(err error) error {
+		return &url.Error{Op: "Get", URL: "https://api.example.test/c/flags", Err: err}
+	}
+	dial := func(err error) error {
+		return transportErr(&net.OpError{Op: "dial", Net: "tcp", Err: err})
+	}
+
+	tests := []struct {
+		name      string
+		err       error
+		sentinel  error
+		retryable bool
+	}{
+		{"unknown host", dial(&net.DNSError{Err: "no such host", Name: "api.example.test", IsNotFound: true}), ErrDNS, false},
+		{"temporary DNS failure", dial(&net.DNSError{Err: "server misbehaving", Name: "api.example.test", IsTemporary: true}), ErrDNS, true},
+		{"DNS timeout", dial(&net.DNSError{Err: "i/o timeout", Name: "api.example.test", IsTimeout: true}), ErrDNS, true},
+		{"connection refused", dial(os.NewSyscallError("connect", syscall.ECONNREFUSED)), ErrConnectionRefused, true},
+		{"connection reset", transportErr(&net.OpError{Op: "read", Net: "tcp", Err: os.NewSyscallError("read", syscall.ECONNRESET)}), nil, true},
+		{"read timeout", transportErr(&net.OpError{Op: "read", Net: "tcp", Err: os.ErrDeadlineExceeded}), nil, true},
+		{"unknown authority", transportErr(&tls.CertificateVerificationError{Err: x509.UnknownAuthorityError{}}), ErrTLS, false},
+		{"wrong host name", transportErr(&tls.CertificateVerificationError{Err: x509.HostnameError{Host: "api.example.test", Certificate: &x509.Certificate{}}}), ErrTLS, false},
+		{"TLS alert from server", transportErr(&net.OpError{Op: "remote error", Err: tls.AlertError(42)}), ErrTLS, false},
+		{"cancelled", transportErr(context.Canceled), nil, false},
+		{"deadline exceeded", transportErr(context.DeadlineExceeded), nil, false},
+	}
+
+	sentinels := []error{ErrDNS, ErrConnectionRefused, ErrTLS}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := classifyNetError(tt.err)
+			if !errors.Is(err, tt.err) {
+				t.Errorf("classifyNetError() = %v, lost the original error", err)
+			}
+			for _, sentinel := range sentinels {
+				if got, want := errors.Is(err, sentinel), sentinel == tt.sentinel; got != want {
+					t.Errorf("errors.Is(%v, %v) = %v, want %v", err, sentinel, got, want)
+				}
+			}
+			if got := IsRetryable(err); got != tt.retryable {
+				t.Errorf("IsRetryable(%v) = %v, want %v", err, got, tt.retryable)
+			}
+		})
+	}
+}
+
+func TestIsRetryable(t *testing.T) {
+	httpErr := func(status int) error {
+		return &HTTPError{StatusCode: status}
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{"nil", nil, false},
+		{"too many requests", &RateLimitError{HTTPError: &HTTPError{StatusCode: http.StatusTooManyRequests}}, true},
+		{"bad gateway", httpErr(http.StatusBadGateway), true},
+		{"service unavailable", httpErr(http.StatusServiceUnavailable), true},
+		{"gateway timeout", httpErr(http.StatusGatewayTimeout), true},
+		{"internal server error", httpErr(http.StatusInternalServerError), false},
+		{"not found", httpErr(http.StatusNotFound), false},
+		{"circuit open", ErrCircuitOpen, true},
+		{"log queue full", ErrLogQueueFull, true},
+		{"wrapped in a ConfigError", &ConfigError{ConfigID: "flags", Err: httpErr(http.StatusServiceUnavailable)}, true},
+		{"decoding failure", errors.New("invalid character"), false},
+	}
+
+	for _, tt := range tests {
+		if got := IsRetryable(tt.err); got != tt.want {
+			t.Errorf("IsRetryable(%s) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestTransportErrorsAreClassified(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+
+	client, err := NewClientWithOptions(server.URL, "app", "secret", WithRetries(0, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result map[string]interface{}
+	err = client.GetConfig("flags", &result)
+	if !errors.Is(err, ErrConnectionRefused) || !IsRetryable(err) {
+		t.Errorf("GetConfig() from a closed server = %v, want a retryable ErrConnectionRefused", err)
+	}
+}
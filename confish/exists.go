@@ -0,0 +1,61 @@
+package confish
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+)
+
+// ConfigExists reports whether the config with the given ID exists, without
+// downloading or decoding it. It sends a HEAD request for the config and
+// returns false on 404, true on a 2xx response and an error otherwise.
+func (c *Client) ConfigExists(configID string) (bool, error) {
+	return c.ConfigExistsContext(context.Background(), configID)
+}
+
+// ConfigExistsContext is like ConfigExists but aborts the request when ctx is cancelled or its deadline passes
+func (c *Client) ConfigExistsContext(ctx context.Context, configID string) (exists bool, err error) {
+	var status int
+	ctx, endSpan := c.startSpan(ctx, SpanConfigExists, map[string]string{"confish.config_id": configID})
+	endObserve := c.observe(OpConfigExists)
+	defer func() {
+		endObserve(status, err)
+		endSpan(status, err)
+		if err != nil {
+			err = c.configError(configID, err)
+		}
+	}()
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	endpoint, err := c.configEndpoint(configID)
+	if err != nil {
+		return false, err
+	}
+
+	req, err := c.newRequest(ctx, "HEAD", endpoint, nil)
+	if err != nil {
+		return false, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if err := c.configLimiter.wait(ctx); err != nil {
+		return false, fmt.Errorf("failed to check config: %w", err)
+	}
+
+	resp, err := c.do(req, true)
+	if err != nil {
+		return false, fmt.Errorf("failed to check config: %w", err)
+	}
+	defer resp.Body.Close()
+
+	status = resp.StatusCode
+	if resp.StatusCode == http.StatusNotFound {
+		return false, nil
+	}
+	if !isSuccess(resp.StatusCode) {
+		return false, fmt.Errorf("failed to check config: %w", newHTTPError(resp, nil))
+	}
+
+	return true, nil
+}
@@ -0,0 +1,71 @@
+package confish
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+)
+
+// GetConfigOrDefault is like GetConfig but falls back to defaultJSON when the
+// API is unavailable, so a service can start during an outage with baked-in
+// safe values. Connection failures, the client's Timeout, an open circuit
+// breaker and 5xx responses trigger the fallback: defaultJSON is unmarshaled
+// into result, the fetch error is passed to the ErrorHandler and nil is
+// returned. Other failures, such as a missing config, bad credentials, a
+// failing TokenSource, an oversized response or a cancelled ctx, are returned
+// as usual.
+func (c *Client) GetConfigOrDefault(configID string, result interface{}, defaultJSON []byte) error {
+	return c.GetConfigOrDefaultContext(context.Background(), configID, result, defaultJSON)
+}
+
+// GetConfigOrDefaultContext is like GetConfigOrDefault but aborts the request when ctx is cancelled or its deadline passes
+func (c *Client) GetConfigOrDefaultContext(ctx context.Context, configID string, result interface{}, defaultJSON []byte) error {
+	if err := checkTarget(result); err != nil {
+		return err
+	}
+
+	body, meta, err := c.fetchConfig(ctx, configID)
+	if err == nil {
+		return c.decodeConfig(configID, body, meta.ContentType, result)
+	}
+
+	if !isUnavailable(ctx, err) {
+		return err
+	}
+
+	if err := c.decodeConfig(configID, defaultJSON, "", result); err != nil {
+		return fmt.Errorf("failed to use default config: %w", err)
+	}
+
+	c.handleError(fmt.Errorf("using default config: %w", err))
+	return nil
+}
+
+// isUnavailable reports whether err means the API could not serve the request
+// at all: the connection failed, the client's Timeout passed, the circuit
+// breaker is open or the server answered with a 5xx. Errors after ctx is done
+// say nothing about the API and are never an outage.
+func isUnavailable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+
+	var authErr *authorizeError
+	if errors.As(err, &authErr) {
+		return false
+	}
+
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		return httpErr.StatusCode >= 500
+	}
+
+	if errors.Is(err, ErrCircuitOpen) || errors.Is(err, context.DeadlineExceeded) || errors.Is(err, io.ErrUnexpectedEOF) {
+		return true
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
@@ -0,0 +1,113 @@
+package confish_test
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bravilogy/confish-go/confish"
+)
+
+func TestGetConfigOrDefaultFallsBackOnlyWhenUnavailable(t *testing.T) {
+	closed := httptest.NewServer(http.NotFoundHandler())
+	closed.Close()
+
+	respond := func(status int, body string) string {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(status)
+			w.Write([]byte(body))
+		}))
+		t.Cleanup(server.Close)
+		return server.URL
+	}
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	t.Cleanup(slow.Close)
+
+	tokenErr := &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("token endpoint unreachable")}
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name     string
+		url      string
+		opts     []confish.Option
+		ctx      context.Context
+		fallback bool
+	}{
+		{"connection refused", closed.URL, nil, nil, true},
+		{"server error", respond(http.StatusServiceUnavailable, ""), nil, nil, true},
+		{"client timeout", slow.URL, []confish.Option{confish.WithTimeout(50 * time.Millisecond)}, nil, true},
+		{"not found", respond(http.StatusNotFound, ""), nil, nil, false},
+		{"response too large", respond(http.StatusOK, `{"name":"`+strings.Repeat("x", 64)+`"}`), []confish.Option{confish.WithMaxResponseBytes(16)}, nil, false},
+		{"token source failure", respond(http.StatusOK, `{}`), []confish.Option{confish.WithTokenSource(func(context.Context) (string, time.Time, error) {
+			return "", time.Time{}, tokenErr
+		})}, nil, false},
+		{"caller cancelled", slow.URL, nil, cancelled, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reported error
+			opts := append([]confish.Option{
+				confish.WithRetries(0, 0),
+				confish.WithErrorHandler(func(err error) { reported = err }),
+			}, tt.opts...)
+			client, err := confish.NewClientWithOptions(tt.url, "app", "secret", opts...)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+			var result struct{ Name string }
+			err = client.GetConfigOrDefaultContext(ctx, "flags", &result, []byte(`{"name":"default"}`))
+
+			if tt.fallback {
+				if err != nil || result.Name != "default" {
+					t.Errorf("GetConfigOrDefault() = %v, %+v, want the default", err, result)
+				}
+				if reported == nil {
+					t.Error("fetch error was not reported to the ErrorHandler")
+				}
+				return
+			}
+			if err == nil {
+				t.Errorf("GetConfigOrDefault() = %+v, want an error instead of the default", result)
+			}
+		})
+	}
+}
+
+func TestGetConfigOrDefaultFallsBackWhenCircuitOpen(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client, err := confish.NewClientWithOptions(server.URL, "app", "secret",
+		confish.WithRetries(0, 0),
+		confish.WithCircuitBreaker(confish.CircuitBreakerConfig{FailureThreshold: 1, Cooldown: time.Hour}),
+		confish.WithErrorHandler(func(error) {}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result struct{ Name string }
+	if err := client.GetConfig("flags", &result); err == nil {
+		t.Fatal("GetConfig() succeeded against a failing server")
+	}
+
+	if err := client.GetConfigOrDefault("flags", &result, []byte(`{"name":"default"}`)); err != nil || result.Name != "default" {
+		t.Errorf("GetConfigOrDefault() with an open circuit = %v, %+v, want the default", err, result)
+	}
+}
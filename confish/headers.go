@@ -0,0 +1,34 @@
+package confish
+
+import (
+	"context"
+	"net/http"
+)
+
+type headersKey struct{}
+
+// ContextWithHeaders returns a context that adds h to every request made with it,
+// on top of ConfishConfig.Headers. Per-call headers replace configured ones
+// with the same name; the client's own auth and content headers always win.
+func ContextWithHeaders(ctx context.Context, h http.Header) context.Context {
+	return context.WithValue(ctx, headersKey{}, h)
+}
+
+// GetConfigWithHeaders is like GetConfig but sends the extra headers h with
+// the request, e.g. a tenant or tracing header
+func (c *Client) GetConfigWithHeaders(configID string, result interface{}, h http.Header) error {
+	return c.GetConfigContext(ContextWithHeaders(context.Background(), h), configID, result)
+}
+
+// applyCustomHeaders copies the configured and per-call headers onto req
+func (c *Client) applyCustomHeaders(req *http.Request) {
+	for name, values := range c.cfg.Headers {
+		req.Header[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+	}
+
+	if h, ok := req.Context().Value(headersKey{}).(http.Header); ok {
+		for name, values := range h {
+			req.Header[http.CanonicalHeaderKey(name)] = append([]string(nil), values...)
+		}
+	}
+}
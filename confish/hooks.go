@@ -0,0 +1,41 @@
+package confish
+
+import "time"
+
+// Operation names passed to RequestHooks
+const (
+	OpGetConfig    = "get_config"
+	OpUpdateConfig = "update_config"
+	OpListConfigs  = "list_configs"
+	OpConfigExists = "config_exists"
+	OpTailLogs     = "tail_logs"
+	OpLog          = "log"
+	OpLogBatch     = "log_batch"
+)
+
+// RequestHooks is notified around every API operation, e.g. to record latency
+// and error metrics in Prometheus or another metrics system. Retries of an
+// operation are included in its duration. Implementations must be safe for
+// concurrent use.
+type RequestHooks interface {
+	// OnRequestStart is called before an operation starts
+	OnRequestStart(op string)
+	// OnRequestEnd is called when an operation is done with the HTTP status
+	// received (zero if none), the time taken and the resulting error
+	OnRequestEnd(op string, status int, dur time.Duration, err error)
+}
+
+// observe reports the start of op to the configured hooks and returns a
+// function reporting its end
+func (c *Client) observe(op string) func(status int, err error) {
+	hooks := c.cfg.Hooks
+	if hooks == nil {
+		return func(int, error) {}
+	}
+
+	start := time.Now()
+	hooks.OnRequestStart(op)
+	return func(status int, err error) {
+		hooks.OnRequestEnd(op, status, time.Since(start), err)
+	}
+}
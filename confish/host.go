@@ -0,0 +1,18 @@
+package confish
+
+import "os"
+
+// hostFields returns the host metadata fields when enabled. The hostname is
+// left out if it cannot be determined.
+func hostFields(enabled bool) map[string]interface{} {
+	if !enabled {
+		return nil
+	}
+
+	fields := map[string]interface{}{"pid": os.Getpid()}
+	if hostname, err := os.Hostname(); err == nil {
+		fields["hostname"] = hostname
+	}
+
+	return fields
+}
@@ -0,0 +1,33 @@
+package confish
+
+import "context"
+
+// Logger is the set of logging methods, satisfied by *Client,
+// confishtest.FakeClient and the fan-out returned by MultiLogger
+type Logger interface {
+	Log(level LogLevel, message string) error
+	LogContext(ctx context.Context, level LogLevel, message string) error
+	LogFields(level LogLevel, message string, fields map[string]interface{}) error
+	Debug(message string) error
+	Info(message string) error
+	Warn(message string) error
+	Error(message string) error
+	Critical(message string) error
+}
+
+// ConfishClient is the set of methods applications typically use, satisfied
+// by *Client. Depend on it instead of *Client to substitute a fake such as
+// confishtest.FakeClient in tests.
+type ConfishClient interface {
+	GetConfig(configID string, result interface{}) error
+	GetConfigContext(ctx context.Context, configID string, result interface{}) error
+
+	Logger
+
+	ProcessWebhookPayload(payload WebhookPayload, result interface{}) error
+}
+
+var (
+	_ ConfishClient = (*Client)(nil)
+	_ Logger        = (*Client)(nil)
+)
@@ -0,0 +1,61 @@
+package confish
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrInvalidLogLevel is returned when a log level is not one of the LogLevel constants
+var ErrInvalidLogLevel = errors.New("confish: invalid log level")
+
+// levelRanks orders the known log levels from least to most severe
+var levelRanks = map[LogLevel]int32{
+	LogLevelDebug:    0,
+	LogLevelInfo:     1,
+	LogLevelWarn:     2,
+	LogLevelError:    3,
+	LogLevelCritical: 4,
+}
+
+// Valid reports whether l is one of the known log levels
+func (l LogLevel) Valid() bool {
+	_, ok := levelRanks[l]
+	return ok
+}
+
+// ParseLogLevel parses a level name such as "warn" or "ERROR", e.g. from a
+// config file or environment variable. Surrounding whitespace and case are
+// ignored.
+func ParseLogLevel(s string) (LogLevel, error) {
+	level := LogLevel(strings.ToLower(strings.TrimSpace(s)))
+	if !level.Valid() {
+		return "", fmt.Errorf("%w: %q", ErrInvalidLogLevel, s)
+	}
+	return level, nil
+}
+
+// rank returns the severity of the level. Unknown levels rank above every
+// known one so that they are never filtered out.
+func (l LogLevel) rank() int32 {
+	if r, ok := levelRanks[l]; ok {
+		return r
+	}
+	return int32(len(levelRanks))
+}
+
+// SetMinLevel changes the minimum level of messages the client sends, for
+// example after a config webhook. Messages below it are dropped without a
+// request. An empty level sends everything.
+func (c *Client) SetMinLevel(level LogLevel) {
+	if level == "" {
+		c.minLevel.Store(0)
+		return
+	}
+	c.minLevel.Store(level.rank())
+}
+
+// levelEnabled reports whether messages at level pass the minimum level
+func (c *Client) levelEnabled(level LogLevel) bool {
+	return level.rank() >= c.minLevel.Load()
+}
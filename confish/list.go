@@ -0,0 +1,111 @@
+package confish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// ConfigSummary describes a config of the app without its values
+type ConfigSummary struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// configListPage is one page of the config list. The API may also answer
+// with a bare array, which is a single page.
+type configListPage struct {
+	Configs    []ConfigSummary `json:"configs"`
+	NextCursor string          `json:"next_cursor"`
+}
+
+// ListConfigs returns every config of the app. When the API paginates the
+// list, all pages are fetched and concatenated.
+func (c *Client) ListConfigs() ([]ConfigSummary, error) {
+	return c.ListConfigsContext(context.Background())
+}
+
+// ListConfigsContext is like ListConfigs but aborts the requests when ctx is cancelled or its deadline passes
+func (c *Client) ListConfigsContext(ctx context.Context) ([]ConfigSummary, error) {
+	var (
+		configs []ConfigSummary
+		cursor  string
+		seen    = make(map[string]bool)
+	)
+
+	for {
+		page, err := c.listConfigsPage(ctx, cursor)
+		if err != nil {
+			return nil, err
+		}
+		configs = append(configs, page.Configs...)
+
+		if page.NextCursor == "" {
+			return configs, nil
+		}
+		if seen[page.NextCursor] {
+			return nil, fmt.Errorf("failed to list configs: cursor %q repeated", page.NextCursor)
+		}
+		seen[page.NextCursor] = true
+		cursor = page.NextCursor
+	}
+}
+
+// listConfigsPage fetches the page of the config list starting at cursor
+func (c *Client) listConfigsPage(ctx context.Context, cursor string) (page configListPage, err error) {
+	var status int
+	ctx, endSpan := c.startSpan(ctx, SpanListConfigs, map[string]string{"confish.app_id": c.appID()})
+	endObserve := c.observe(OpListConfigs)
+	defer func() {
+		endObserve(status, err)
+		endSpan(status, err)
+	}()
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	endpoint := c.endpoint("a", c.appID(), "configs")
+	if cursor != "" {
+		endpoint += "?" + url.Values{"cursor": {cursor}}.Encode()
+	}
+
+	req, err := c.newRequest(ctx, "GET", endpoint, nil)
+	if err != nil {
+		return page, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if err := c.configLimiter.wait(ctx); err != nil {
+		return page, fmt.Errorf("failed to list configs: %w", err)
+	}
+
+	resp, err := c.do(req, true)
+	if err != nil {
+		return page, fmt.Errorf("failed to list configs: %w", err)
+	}
+	defer resp.Body.Close()
+
+	status = resp.StatusCode
+	if !isSuccess(resp.StatusCode) {
+		return page, fmt.Errorf("failed to list configs: %w", newHTTPError(resp, nil))
+	}
+
+	body, err := c.readBody(resp.Body)
+	if err != nil {
+		return page, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &page.Configs)
+	} else {
+		err = json.Unmarshal(body, &page)
+	}
+	if err != nil {
+		return configListPage{}, fmt.Errorf("failed to unmarshal config list: %w", err)
+	}
+
+	return page, nil
+}
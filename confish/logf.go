@@ -0,0 +1,57 @@
+package confish
+
+import (
+	"fmt"
+	"runtime/debug"
+)
+
+// Logf formats a message with fmt.Sprintf and logs it. Nothing is formatted
+// when the level is below the minimum level.
+func (c *Client) Logf(level LogLevel, format string, args ...interface{}) error {
+	if level.Valid() && !c.levelEnabled(level) {
+		return nil
+	}
+
+	return c.Log(level, fmt.Sprintf(format, args...))
+}
+
+// Debugf logs a formatted debug message
+func (c *Client) Debugf(format string, args ...interface{}) error {
+	return c.Logf(LogLevelDebug, format, args...)
+}
+
+// Infof logs a formatted info message
+func (c *Client) Infof(format string, args ...interface{}) error {
+	return c.Logf(LogLevelInfo, format, args...)
+}
+
+// Warnf logs a formatted warning message
+func (c *Client) Warnf(format string, args ...interface{}) error {
+	return c.Logf(LogLevelWarn, format, args...)
+}
+
+// Errorf logs a formatted error message
+func (c *Client) Errorf(format string, args ...interface{}) error {
+	return c.Logf(LogLevelError, format, args...)
+}
+
+// Criticalf logs a formatted critical message
+func (c *Client) Criticalf(format string, args ...interface{}) error {
+	return c.Logf(LogLevelCritical, format, args...)
+}
+
+// ErrorErr logs err at the error level with its message as the log message
+// and its type in the "error_type" field. With ErrorStacks enabled, the
+// caller's stack trace is added in the "stack" field. A nil err logs nothing.
+func (c *Client) ErrorErr(err error) error {
+	if err == nil || !c.levelEnabled(LogLevelError) {
+		return nil
+	}
+
+	fields := map[string]interface{}{"error_type": fmt.Sprintf("%T", err)}
+	if c.cfg.ErrorStacks {
+		fields["stack"] = string(debug.Stack())
+	}
+
+	return c.LogFields(LogLevelError, err.Error(), fields)
+}
@@ -0,0 +1,94 @@
+package confish
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// maxConcurrentFetches bounds the number of requests GetConfigs runs at once
+const maxConcurrentFetches = 8
+
+// ConfigsError is returned by GetConfigs when some of the configs could not be
+// fetched. Errors holds the failure for each such config ID.
+type ConfigsError struct {
+	Errors map[string]error
+}
+
+func (e *ConfigsError) Error() string {
+	ids := make([]string, 0, len(e.Errors))
+	for id := range e.Errors {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	// Each error is a *ConfigError, whose message already names the config
+	parts := make([]string, len(ids))
+	for i, id := range ids {
+		parts[i] = e.Errors[id].Error()
+	}
+
+	return fmt.Sprintf("failed to fetch %d config(s): %s", len(ids), strings.Join(parts, "; "))
+}
+
+// Unwrap returns the individual errors so errors.Is and errors.As see them
+func (e *ConfigsError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, err := range e.Errors {
+		errs = append(errs, err)
+	}
+	return errs
+}
+
+// GetConfigs fetches several configs concurrently and returns their raw values
+// keyed by ID. Configs that fail to fetch are left out of the map and reported
+// together in a *ConfigsError; the successful ones are still returned.
+func (c *Client) GetConfigs(ids []string) (map[string]json.RawMessage, error) {
+	return c.GetConfigsContext(context.Background(), ids)
+}
+
+// GetConfigsContext is like GetConfigs but aborts the requests when ctx is cancelled or its deadline passes
+func (c *Client) GetConfigsContext(ctx context.Context, ids []string) (map[string]json.RawMessage, error) {
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		results = make(map[string]json.RawMessage, len(ids))
+		errs    = make(map[string]error)
+		sem     = make(chan struct{}, maxConcurrentFetches)
+		seen    = make(map[string]bool, len(ids))
+	)
+
+	for _, id := range ids {
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(id string) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			body, _, err := c.fetchConfig(ctx, id)
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				errs[id] = err
+				return
+			}
+			results[id] = json.RawMessage(body)
+		}(id)
+	}
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return results, &ConfigsError{Errors: errs}
+	}
+
+	return results, nil
+}
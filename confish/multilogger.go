@@ -0,0 +1,59 @@
+package confish
+
+import (
+	"context"
+	"errors"
+)
+
+// multiLogger writes every entry to each of its loggers in turn
+type multiLogger []Logger
+
+// MultiLogger returns a Logger that writes every entry to all loggers, e.g. to
+// dual-write to two Confish apps, or to Confish and a client created
+// WithDryRun(os.Stdout), during a migration. Every logger is called even when
+// an earlier one fails; the errors are combined with errors.Join.
+func MultiLogger(loggers ...Logger) Logger {
+	return multiLogger(append([]Logger(nil), loggers...))
+}
+
+func (m multiLogger) each(log func(Logger) error) error {
+	var errs []error
+	for _, l := range m {
+		if err := log(l); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m multiLogger) Log(level LogLevel, message string) error {
+	return m.each(func(l Logger) error { return l.Log(level, message) })
+}
+
+func (m multiLogger) LogContext(ctx context.Context, level LogLevel, message string) error {
+	return m.each(func(l Logger) error { return l.LogContext(ctx, level, message) })
+}
+
+func (m multiLogger) LogFields(level LogLevel, message string, fields map[string]interface{}) error {
+	return m.each(func(l Logger) error { return l.LogFields(level, message, fields) })
+}
+
+func (m multiLogger) Debug(message string) error {
+	return m.each(func(l Logger) error { return l.Debug(message) })
+}
+
+func (m multiLogger) Info(message string) error {
+	return m.each(func(l Logger) error { return l.Info(message) })
+}
+
+func (m multiLogger) Warn(message string) error {
+	return m.each(func(l Logger) error { return l.Warn(message) })
+}
+
+func (m multiLogger) Error(message string) error {
+	return m.each(func(l Logger) error { return l.Error(message) })
+}
+
+func (m multiLogger) Critical(message string) error {
+	return m.each(func(l Logger) error { return l.Critical(message) })
+}
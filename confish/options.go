@@ -0,0 +1,318 @@
+package confish
+
+import (
+	"crypto/tls"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Option configures a client created with NewClientWithOptions
+type Option func(*ConfishConfig)
+
+// NewClientWithOptions creates a new Confish client from the required
+// connection settings and any number of options
+func NewClientWithOptions(url, appID, appSecret string, opts ...Option) (*Client, error) {
+	cfg := &ConfishConfig{
+		URL:       url,
+		AppID:     appID,
+		AppSecret: appSecret,
+	}
+
+	for _, opt := range opts {
+		opt(cfg)
+	}
+
+	return NewClient(cfg)
+}
+
+// WithWebhookPath sets the path webhooks are received on
+func WithWebhookPath(path string) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.WebhookPath = path
+	}
+}
+
+// WithPathTemplates sets the API paths of configs and of the log endpoint,
+// e.g. "/v2/c/{id}" and "/v2/a/{appID}/log"
+func WithPathTemplates(configTemplate, logTemplate string) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.ConfigPathTemplate = configTemplate
+		cfg.LogPathTemplate = logTemplate
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for all requests
+func WithHTTPClient(client *http.Client) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.HTTPClient = client
+	}
+}
+
+// WithTimeout bounds each request to d
+func WithTimeout(d time.Duration) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.Timeout = d
+	}
+}
+
+// WithRetries retries failed requests up to maxRetries times, starting with a
+// backoff of baseDelay
+func WithRetries(maxRetries int, baseDelay time.Duration) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.MaxRetries = maxRetries
+		cfg.RetryBaseDelay = baseDelay
+	}
+}
+
+// WithCacheTTL enables the in-memory config cache used by GetConfigCached
+func WithCacheTTL(ttl time.Duration) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.CacheTTL = ttl
+	}
+}
+
+// WithMaxResponseBytes fails requests whose response body exceeds n bytes
+// with ErrResponseTooLarge
+func WithMaxResponseBytes(n int64) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.MaxResponseBytes = n
+	}
+}
+
+// WithSingleflight enables or disables sharing one request among concurrent
+// GetConfigCached calls for the same config. It is enabled by default.
+func WithSingleflight(enabled bool) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.DisableSingleflight = !enabled
+	}
+}
+
+// WithCompression enables gzip compression of requests and responses
+func WithCompression() Option {
+	return func(cfg *ConfishConfig) {
+		cfg.EnableCompression = true
+	}
+}
+
+// WithBatching enables batched log delivery
+func WithBatching(batch BatchConfig) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.Batch = &batch
+	}
+}
+
+// WithErrorHandler sets the handler for background log delivery errors
+func WithErrorHandler(handler func(error)) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.ErrorHandler = handler
+	}
+}
+
+// WithMinLevel drops log messages below level
+func WithMinLevel(level LogLevel) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.MinLevel = level
+	}
+}
+
+// WithDefaultFields attaches fields to every log payload
+func WithDefaultFields(fields map[string]interface{}) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.DefaultFields = fields
+	}
+}
+
+// WithHeaders adds headers to every request
+func WithHeaders(h http.Header) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.Headers = h
+	}
+}
+
+// WithHooks reports every API operation to hooks
+func WithHooks(hooks RequestHooks) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.Hooks = hooks
+	}
+}
+
+// WithRequestIDs enables or disables sending a generated X-Request-Id header
+func WithRequestIDs(enabled bool) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.RequestIDs = enabled
+	}
+}
+
+// WithIdempotencyKeys sends an Idempotency-Key header with every log write so
+// retried writes can be deduplicated by the server
+func WithIdempotencyKeys() Option {
+	return func(cfg *ConfishConfig) {
+		cfg.IdempotencyKeys = true
+	}
+}
+
+// WithDiskCache saves fetched configs in dir and falls back to them when the
+// API is unavailable
+func WithDiskCache(dir string) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.DiskCacheDir = dir
+	}
+}
+
+// WithUserAgent sets the service name sent ahead of the library's User-Agent
+func WithUserAgent(userAgent string) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.UserAgent = userAgent
+	}
+}
+
+// WithUseNumber decodes JSON numbers in configs as json.Number
+func WithUseNumber() Option {
+	return func(cfg *ConfishConfig) {
+		cfg.UseNumber = true
+	}
+}
+
+// WithStrictDecode rejects JSON configs with keys the result doesn't declare
+func WithStrictDecode() Option {
+	return func(cfg *ConfishConfig) {
+		cfg.StrictDecode = true
+	}
+}
+
+// WithAllowEmptyConfig decodes empty config bodies as the zero value
+func WithAllowEmptyConfig() Option {
+	return func(cfg *ConfishConfig) {
+		cfg.AllowEmptyConfig = true
+	}
+}
+
+// WithDryRun writes log entries to w instead of sending them. A nil w means
+// os.Stderr.
+func WithDryRun(w io.Writer) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.DryRun = true
+		cfg.DryRunWriter = w
+	}
+}
+
+// WithConnectionPool tunes connection reuse of the built-in transport
+func WithConnectionPool(maxIdleConnsPerHost int, idleConnTimeout time.Duration) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.MaxIdleConnsPerHost = maxIdleConnsPerHost
+		cfg.IdleConnTimeout = idleConnTimeout
+	}
+}
+
+// WithTLSConfig sets the TLS configuration for connections to the API
+func WithTLSConfig(tlsConfig *tls.Config) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.TLSConfig = tlsConfig
+	}
+}
+
+// WithProxy routes requests through the proxy at proxyURL
+func WithProxy(proxyURL string) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.ProxyURL = proxyURL
+	}
+}
+
+// WithClientCertificate presents the PEM certificate and key in certFile and
+// keyFile for mutual TLS
+func WithClientCertificate(certFile, keyFile string) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.ClientCertFile = certFile
+		cfg.ClientKeyFile = keyFile
+	}
+}
+
+// WithRateLimit throttles config and log requests, each with its own bucket
+// allowing rl
+func WithRateLimit(rl RateLimit) Option {
+	return func(cfg *ConfishConfig) {
+		configLimit, logLimit := rl, rl
+		cfg.ConfigRateLimit = &configLimit
+		cfg.LogRateLimit = &logLimit
+	}
+}
+
+// WithCircuitBreaker enables the circuit breaker
+func WithCircuitBreaker(breaker CircuitBreakerConfig) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.CircuitBreaker = &breaker
+	}
+}
+
+// WithRetryBudget caps retries across all requests of the client
+func WithRetryBudget(budget RetryBudget) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.RetryBudget = &budget
+	}
+}
+
+// WithAppSecretFile reads the app secret from path. Pass an empty appSecret
+// to NewClientWithOptions when using it.
+func WithAppSecretFile(path string) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.AppSecretFile = path
+	}
+}
+
+// WithDebugLogger reports every HTTP attempt to logf, e.g. log.Printf
+func WithDebugLogger(logf func(format string, args ...interface{})) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.DebugLogger = logf
+	}
+}
+
+// WithLogDedup collapses identical consecutive log entries within window
+func WithLogDedup(window time.Duration) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.LogDedupWindow = window
+	}
+}
+
+// WithAuthorizer authenticates requests with auth instead of the app secret.
+// Pass an empty appSecret to NewClientWithOptions unless webhooks are verified.
+func WithAuthorizer(auth Authorizer) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.Authorizer = auth
+	}
+}
+
+// WithTokenSource authenticates requests with bearer tokens from source
+func WithTokenSource(source TokenSource) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.TokenSource = source
+	}
+}
+
+// WithPollJitter spreads WatchConfig polls by up to fraction of the interval
+func WithPollJitter(fraction float64) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.PollJitter = fraction
+	}
+}
+
+// WithMarshaler serializes log payloads with marshal instead of json.Marshal
+func WithMarshaler(marshal func(v interface{}) ([]byte, error)) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.Marshal = marshal
+	}
+}
+
+// WithUnmarshaler decodes JSON configs with unmarshal instead of encoding/json
+func WithUnmarshaler(unmarshal UnmarshalFunc) Option {
+	return func(cfg *ConfishConfig) {
+		cfg.Unmarshal = unmarshal
+	}
+}
+
+// WithHostMetadata tags every log entry with the hostname and pid
+func WithHostMetadata() Option {
+	return func(cfg *ConfishConfig) {
+		cfg.HostMetadata = true
+	}
+}
@@ -0,0 +1,17 @@
+module github.com/bravilogy/confish-go/confish/otelconfish
+
+go 1.22.0
+
+require (
+	github.com/bravilogy/confish-go v0.0.0
+	go.opentelemetry.io/otel v1.31.0
+	go.opentelemetry.io/otel/trace v1.31.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	go.opentelemetry.io/otel/metric v1.31.0 // indirect
+)
+
+replace github.com/bravilogy/confish-go => ../..
@@ -0,0 +1,78 @@
+// Package otelconfish connects the confish client to OpenTelemetry tracing.
+// It is a separate module so that applications not using OpenTelemetry don't
+// depend on it.
+package otelconfish
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/bravilogy/confish-go/confish"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName identifies the tracer created by this package
+const instrumentationName = "github.com/bravilogy/confish-go/confish"
+
+// Tracer is a confish.Tracer backed by an OpenTelemetry TracerProvider. It
+// starts a client span per operation and injects a W3C traceparent header
+// into outgoing requests.
+type Tracer struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+var _ confish.Tracer = (*Tracer)(nil)
+
+// NewTracer creates a Tracer from tp, falling back to the global
+// TracerProvider when tp is nil
+func NewTracer(tp trace.TracerProvider) *Tracer {
+	if tp == nil {
+		tp = otel.GetTracerProvider()
+	}
+
+	return &Tracer{
+		tracer:     tp.Tracer(instrumentationName),
+		propagator: propagation.TraceContext{},
+	}
+}
+
+// WithTracing traces client requests with tp
+func WithTracing(tp trace.TracerProvider) confish.Option {
+	return func(cfg *confish.ConfishConfig) {
+		cfg.Tracer = NewTracer(tp)
+	}
+}
+
+// Start begins a client span
+func (t *Tracer) Start(ctx context.Context, name string, attrs map[string]string) (context.Context, func(status int, err error)) {
+	kvs := make([]attribute.KeyValue, 0, len(attrs))
+	for k, v := range attrs {
+		kvs = append(kvs, attribute.String(k, v))
+	}
+
+	ctx, span := t.tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(kvs...),
+	)
+
+	return ctx, func(status int, err error) {
+		if status > 0 {
+			span.SetAttributes(attribute.Int("http.response.status_code", status))
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}
+}
+
+// Inject writes the trace context into header
+func (t *Tracer) Inject(ctx context.Context, header http.Header) {
+	t.propagator.Inject(ctx, propagation.HeaderCarrier(header))
+}
@@ -0,0 +1,365 @@
+package confish
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// ApplyWebhookPatch returns the config values described by a webhook, given
+// the current values. Payloads that carry full values replace current.
+// Payloads that only carry a patch have it applied to current: a JSON array
+// is treated as an RFC 6902 JSON Patch and anything else as an RFC 7386
+// merge patch. Deleted events return nil values. Any other event yields an
+// *UnsupportedEventError.
+func ApplyWebhookPatch(current json.RawMessage, payload WebhookPayload) (json.RawMessage, error) {
+	switch payload.Event {
+	case EventConfigurationCreated, EventConfigurationUpdated:
+	case EventConfigurationDeleted:
+		return nil, nil
+	default:
+		return nil, &UnsupportedEventError{Event: payload.Event}
+	}
+
+	cfg := payload.Configuration
+	if hasJSON(cfg.Values) || !hasJSON(cfg.Patch) {
+		return cfg.Values, nil
+	}
+
+	var doc interface{}
+	if hasJSON(current) {
+		if err := unmarshalNumbers(current, &doc); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal current values: %w", err)
+		}
+	}
+
+	var err error
+	if bytes.TrimSpace(cfg.Patch)[0] == '[' {
+		doc, err = applyJSONPatch(doc, cfg.Patch)
+	} else {
+		var patch interface{}
+		if err = unmarshalNumbers(cfg.Patch, &patch); err == nil {
+			doc = applyMergePatch(doc, patch)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply patch: %w", err)
+	}
+
+	return json.Marshal(doc)
+}
+
+// hasJSON reports whether raw holds a value other than null
+func hasJSON(raw json.RawMessage) bool {
+	raw = bytes.TrimSpace(raw)
+	return len(raw) > 0 && !bytes.Equal(raw, []byte("null"))
+}
+
+// unmarshalNumbers decodes data keeping numbers exact as json.Number
+func unmarshalNumbers(data []byte, v interface{}) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+// applyMergePatch implements RFC 7386
+func applyMergePatch(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = make(map[string]interface{}, len(p))
+	}
+
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = applyMergePatch(t[k], v)
+	}
+
+	return t
+}
+
+type patchOperation struct {
+	Op    string          `json:"op"`
+	Path  *string         `json:"path"`
+	From  *string         `json:"from"`
+	Value json.RawMessage `json:"value"`
+}
+
+// applyJSONPatch implements RFC 6902. Operations are applied in order and
+// stop at the first one that fails.
+func applyJSONPatch(doc interface{}, patch json.RawMessage) (interface{}, error) {
+	var ops []patchOperation
+	if err := json.Unmarshal(patch, &ops); err != nil {
+		return nil, err
+	}
+
+	for i, op := range ops {
+		var err error
+		doc, err = applyPatchOperation(doc, op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s): %w", i, op.Op, err)
+		}
+	}
+
+	return doc, nil
+}
+
+func applyPatchOperation(doc interface{}, op patchOperation) (interface{}, error) {
+	if op.Path == nil {
+		return nil, errors.New("missing path")
+	}
+	path, err := parsePointer(*op.Path)
+	if err != nil {
+		return nil, err
+	}
+
+	var from []string
+	if op.Op == "move" || op.Op == "copy" {
+		if op.From == nil {
+			return nil, errors.New("missing from")
+		}
+		if from, err = parsePointer(*op.From); err != nil {
+			return nil, err
+		}
+	}
+
+	var value interface{}
+	if op.Op == "add" || op.Op == "replace" || op.Op == "test" {
+		if op.Value == nil {
+			return nil, errors.New("missing value")
+		}
+		if err := unmarshalNumbers(op.Value, &value); err != nil {
+			return nil, fmt.Errorf("invalid value: %w", err)
+		}
+	}
+
+	switch op.Op {
+	case "add":
+		return pointerAdd(doc, path, value)
+	case "remove":
+		return pointerRemove(doc, path)
+	case "replace":
+		if len(path) == 0 {
+			return value, nil
+		}
+		if doc, err = pointerRemove(doc, path); err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, path, value)
+	case "move":
+		if isPrefix(from, path) && len(from) < len(path) {
+			return nil, errors.New("cannot move a value into itself")
+		}
+		moved, err := pointerGet(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if doc, err = pointerRemove(doc, from); err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, path, moved)
+	case "copy":
+		copied, err := pointerGet(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if copied, err = deepCopyJSON(copied); err != nil {
+			return nil, err
+		}
+		return pointerAdd(doc, path, copied)
+	case "test":
+		actual, err := pointerGet(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		equal, err := jsonEqual(actual, value)
+		if err != nil {
+			return nil, err
+		}
+		if !equal {
+			return nil, fmt.Errorf("test failed at %q", *op.Path)
+		}
+		return doc, nil
+	default:
+		return nil, errors.New("unknown operation")
+	}
+}
+
+// parsePointer splits an RFC 6901 JSON Pointer into unescaped reference tokens
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return nil, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid JSON pointer %q", pointer)
+	}
+
+	tokens := strings.Split(pointer[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+
+	return tokens, nil
+}
+
+func isPrefix(prefix, path []string) bool {
+	if len(prefix) > len(path) {
+		return false
+	}
+	for i := range prefix {
+		if prefix[i] != path[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// arrayIndex parses an array index token, which must be in [0, max]
+func arrayIndex(token string, max int) (int, error) {
+	if token == "" || (len(token) > 1 && token[0] == '0') || strings.Trim(token, "0123456789") != "" {
+		return 0, fmt.Errorf("invalid array index %q", token)
+	}
+	i, err := strconv.Atoi(token)
+	if err != nil || i < 0 || i > max {
+		return 0, fmt.Errorf("array index %q out of range", token)
+	}
+	return i, nil
+}
+
+func pointerGet(doc interface{}, path []string) (interface{}, error) {
+	node := doc
+	for _, token := range path {
+		switch n := node.(type) {
+		case map[string]interface{}:
+			v, ok := n[token]
+			if !ok {
+				return nil, fmt.Errorf("path member %q not found", token)
+			}
+			node = v
+		case []interface{}:
+			i, err := arrayIndex(token, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			node = n[i]
+		default:
+			return nil, fmt.Errorf("cannot index into %q", token)
+		}
+	}
+	return node, nil
+}
+
+// pointerUpdate replaces the container the last token of path refers into
+// with the result of update and returns the resulting document
+func pointerUpdate(doc interface{}, path []string, update func(parent interface{}, token string) (interface{}, error)) (interface{}, error) {
+	if len(path) == 1 {
+		return update(doc, path[0])
+	}
+
+	child, err := pointerGet(doc, path[:1])
+	if err != nil {
+		return nil, err
+	}
+	child, err = pointerUpdate(child, path[1:], update)
+	if err != nil {
+		return nil, err
+	}
+
+	switch n := doc.(type) {
+	case map[string]interface{}:
+		n[path[0]] = child
+	case []interface{}:
+		i, _ := arrayIndex(path[0], len(n)-1)
+		n[i] = child
+	}
+	return doc, nil
+}
+
+func pointerAdd(doc interface{}, path []string, value interface{}) (interface{}, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+
+	return pointerUpdate(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch n := parent.(type) {
+		case map[string]interface{}:
+			n[token] = value
+			return n, nil
+		case []interface{}:
+			if token == "-" {
+				return append(n, value), nil
+			}
+			i, err := arrayIndex(token, len(n))
+			if err != nil {
+				return nil, err
+			}
+			n = append(n, nil)
+			copy(n[i+1:], n[i:])
+			n[i] = value
+			return n, nil
+		default:
+			return nil, fmt.Errorf("cannot add %q to a non-container value", token)
+		}
+	})
+}
+
+func pointerRemove(doc interface{}, path []string) (interface{}, error) {
+	if len(path) == 0 {
+		return nil, errors.New("cannot remove the whole document")
+	}
+
+	return pointerUpdate(doc, path, func(parent interface{}, token string) (interface{}, error) {
+		switch n := parent.(type) {
+		case map[string]interface{}:
+			if _, ok := n[token]; !ok {
+				return nil, fmt.Errorf("path member %q not found", token)
+			}
+			delete(n, token)
+			return n, nil
+		case []interface{}:
+			i, err := arrayIndex(token, len(n)-1)
+			if err != nil {
+				return nil, err
+			}
+			return append(n[:i], n[i+1:]...), nil
+		default:
+			return nil, fmt.Errorf("cannot remove %q from a non-container value", token)
+		}
+	})
+}
+
+func deepCopyJSON(v interface{}) (interface{}, error) {
+	raw, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var copied interface{}
+	err = unmarshalNumbers(raw, &copied)
+	return copied, err
+}
+
+// jsonEqual compares two decoded JSON values, treating numbers by value
+func jsonEqual(a, b interface{}) (bool, error) {
+	var values [2]interface{}
+	for i, v := range []interface{}{a, b} {
+		raw, err := json.Marshal(v)
+		if err != nil {
+			return false, err
+		}
+		if err := json.Unmarshal(raw, &values[i]); err != nil {
+			return false, err
+		}
+	}
+	return reflect.DeepEqual(values[0], values[1]), nil
+}
@@ -0,0 +1,169 @@
+package confish_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/bravilogy/confish-go/confish"
+)
+
+// patchPayload builds an update payload carrying only patch
+func patchPayload(patch string) confish.WebhookPayload {
+	return confish.WebhookPayload{
+		Event:         confish.EventConfigurationUpdated,
+		Configuration: confish.ConfigurationObject{Name: "cfg", Patch: json.RawMessage(patch)},
+	}
+}
+
+// equalJSON reports whether a and b encode the same JSON value
+func equalJSON(t *testing.T, a, b []byte) bool {
+	t.Helper()
+
+	var va, vb interface{}
+	if err := json.Unmarshal(a, &va); err != nil {
+		t.Fatalf("invalid JSON %s: %v", a, err)
+	}
+	if err := json.Unmarshal(b, &vb); err != nil {
+		t.Fatalf("invalid JSON %s: %v", b, err)
+	}
+	return reflect.DeepEqual(va, vb)
+}
+
+func TestApplyWebhookPatch(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		patch   string
+		want    string
+	}{
+		{"add member", `{"a":1}`, `[{"op":"add","path":"/b","value":2}]`, `{"a":1,"b":2}`},
+		{"add replaces member", `{"a":1}`, `[{"op":"add","path":"/a","value":[1]}]`, `{"a":[1]}`},
+		{"add array element", `{"a":[1,3]}`, `[{"op":"add","path":"/a/1","value":2}]`, `{"a":[1,2,3]}`},
+		{"add at array end index", `{"a":[1]}`, `[{"op":"add","path":"/a/1","value":2}]`, `{"a":[1,2]}`},
+		{"add with dash index", `{"a":[1,2]}`, `[{"op":"add","path":"/a/-","value":3}]`, `{"a":[1,2,3]}`},
+		{"add whole document", `{"a":1}`, `[{"op":"add","path":"","value":{"b":2}}]`, `{"b":2}`},
+		{"remove member", `{"a":1,"b":2}`, `[{"op":"remove","path":"/a"}]`, `{"b":2}`},
+		{"remove array element", `{"a":[1,2,3]}`, `[{"op":"remove","path":"/a/0"}]`, `{"a":[2,3]}`},
+		{"replace nested", `{"a":{"b":1}}`, `[{"op":"replace","path":"/a/b","value":"x"}]`, `{"a":{"b":"x"}}`},
+		{"move", `{"a":{"b":1},"c":{}}`, `[{"op":"move","from":"/a/b","path":"/c/d"}]`, `{"a":{},"c":{"d":1}}`},
+		{"move array element", `{"a":[1,2,3]}`, `[{"op":"move","from":"/a/0","path":"/a/-"}]`, `{"a":[2,3,1]}`},
+		{"copy", `{"a":{"b":[1]}}`, `[{"op":"copy","from":"/a","path":"/c"}]`, `{"a":{"b":[1]},"c":{"b":[1]}}`},
+		{"copy is deep", `{"a":{"b":1}}`, `[{"op":"copy","from":"/a","path":"/c"},{"op":"replace","path":"/c/b","value":2}]`, `{"a":{"b":1},"c":{"b":2}}`},
+		{"test passes", `{"a":{"b":[1,"x"]}}`, `[{"op":"test","path":"/a","value":{"b":[1,"x"]}},{"op":"add","path":"/ok","value":true}]`, `{"a":{"b":[1,"x"]},"ok":true}`},
+		{"test compares numbers by value", `{"a":1}`, `[{"op":"test","path":"/a","value":1.0}]`, `{"a":1}`},
+		{"tilde escape", `{"a~b":1}`, `[{"op":"replace","path":"/a~0b","value":2}]`, `{"a~b":2}`},
+		{"slash escape", `{"a/b":1}`, `[{"op":"remove","path":"/a~1b"}]`, `{}`},
+		{"escapes decoded in order", `{"~1":1}`, `[{"op":"replace","path":"/~01","value":2}]`, `{"~1":2}`},
+		{"merge patch", `{"a":1,"b":{"c":2}}`, `{"b":{"d":3},"e":4}`, `{"a":1,"b":{"c":2,"d":3},"e":4}`},
+		{"merge patch null deletes", `{"a":1,"b":{"c":2,"d":3}}`, `{"a":null,"b":{"c":null}}`, `{"b":{"d":3}}`},
+		{"merge patch replaces arrays", `{"a":[1,2]}`, `{"a":[3]}`, `{"a":[3]}`},
+		{"merge patch without current", ``, `{"a":{"b":null,"c":1}}`, `{"a":{"c":1}}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := confish.ApplyWebhookPatch(json.RawMessage(tt.current), patchPayload(tt.patch))
+			if err != nil {
+				t.Fatalf("ApplyWebhookPatch() error = %v", err)
+			}
+			if !equalJSON(t, got, []byte(tt.want)) {
+				t.Errorf("ApplyWebhookPatch() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyWebhookPatchKeepsLargeIntegers(t *testing.T) {
+	got, err := confish.ApplyWebhookPatch(json.RawMessage(`{"a":9007199254740993}`), patchPayload(`[{"op":"add","path":"/b","value":9007199254740995}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"a":9007199254740993,"b":9007199254740995}`; string(got) != want {
+		t.Errorf("ApplyWebhookPatch() = %s, want %s", got, want)
+	}
+}
+
+func TestApplyWebhookPatchErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		current string
+		patch   string
+	}{
+		{"index past end", `{"a":[1]}`, `[{"op":"add","path":"/a/2","value":2}]`},
+		{"negative index", `{"a":[1]}`, `[{"op":"replace","path":"/a/-1","value":2}]`},
+		{"leading zero index", `{"a":[1,2]}`, `[{"op":"remove","path":"/a/01"}]`},
+		{"dash index outside add", `{"a":[1]}`, `[{"op":"remove","path":"/a/-"}]`},
+		{"remove past end", `{"a":[1]}`, `[{"op":"remove","path":"/a/1"}]`},
+		{"remove missing member", `{"a":1}`, `[{"op":"remove","path":"/b"}]`},
+		{"replace missing member", `{"a":1}`, `[{"op":"replace","path":"/b","value":1}]`},
+		{"add below missing parent", `{}`, `[{"op":"add","path":"/a/b","value":1}]`},
+		{"move into itself", `{"a":{"b":1}}`, `[{"op":"move","from":"/a","path":"/a/b/c"}]`},
+		{"copy missing from", `{}`, `[{"op":"copy","from":"/x","path":"/y"}]`},
+		{"test fails", `{"a":1}`, `[{"op":"test","path":"/a","value":2}]`},
+		{"unknown op", `{}`, `[{"op":"frob","path":"/a"}]`},
+		{"missing path", `{}`, `[{"op":"remove"}]`},
+		{"missing value", `{}`, `[{"op":"add","path":"/a"}]`},
+		{"invalid pointer", `{}`, `[{"op":"add","path":"a","value":1}]`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := confish.ApplyWebhookPatch(json.RawMessage(tt.current), patchPayload(tt.patch)); err == nil {
+				t.Errorf("ApplyWebhookPatch() = %s, want error", got)
+			}
+		})
+	}
+}
+
+func TestApplyWebhookPatchFailureLeavesCurrentUnchanged(t *testing.T) {
+	current := json.RawMessage(`{"a":[1,2],"b":{"c":1}}`)
+	before := string(current)
+
+	patch := `[{"op":"remove","path":"/a/0"},{"op":"add","path":"/b/d","value":2},{"op":"test","path":"/b/c","value":9}]`
+	if got, err := confish.ApplyWebhookPatch(current, patchPayload(patch)); err == nil {
+		t.Fatalf("ApplyWebhookPatch() = %s, want error from the failed test", got)
+	}
+	if string(current) != before {
+		t.Errorf("current = %s after a failed patch, want %s", current, before)
+	}
+
+	// A store keeps its value when a webhook's patch fails
+	client, err := confish.NewClientWithOptions("https://api.example.test", "app", "secret", confish.WithErrorHandler(func(error) {}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	type doc struct {
+		A []int          `json:"a"`
+		B map[string]int `json:"b"`
+	}
+	store := confish.NewConfigStore(doc{A: []int{1, 2}, B: map[string]int{"c": 1}})
+	sendWebhook(t, store.WebhookHandler(client, "cfg"), "secret", patchPayload(patch))
+	if got := store.Load(); !reflect.DeepEqual(got, doc{A: []int{1, 2}, B: map[string]int{"c": 1}}) {
+		t.Errorf("store value after a failed patch = %+v, want it unchanged", got)
+	}
+
+	// The same current values still take a valid patch from scratch
+	got, err := confish.ApplyWebhookPatch(current, patchPayload(`[{"op":"add","path":"/b/d","value":2}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"a":[1,2],"b":{"c":1,"d":2}}`; !equalJSON(t, got, []byte(want)) {
+		t.Errorf("ApplyWebhookPatch() = %s, want %s", got, want)
+	}
+}
+
+func TestApplyWebhookPatchPassesValuesThrough(t *testing.T) {
+	payload := patchPayload(`[{"op":"remove","path":"/a"}]`)
+	payload.Configuration.Values = json.RawMessage(`{"full":true}`)
+
+	got, err := confish.ApplyWebhookPatch(json.RawMessage(`{"a":1}`), payload)
+	if err != nil || string(got) != `{"full":true}` {
+		t.Errorf("ApplyWebhookPatch() = %s, %v, want the full values", got, err)
+	}
+
+	payload.Event = confish.EventConfigurationDeleted
+	if got, err := confish.ApplyWebhookPatch(json.RawMessage(`{"a":1}`), payload); err != nil || got != nil {
+		t.Errorf("ApplyWebhookPatch() for a delete = %s, %v, want nil values", got, err)
+	}
+}
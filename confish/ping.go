@@ -0,0 +1,46 @@
+package confish
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// ErrUnreachable is matched by Ping errors caused by a connection failure
+// rather than an API response
+var ErrUnreachable = errors.New("confish: API unreachable")
+
+// Ping checks connectivity and credentials, e.g. for a readiness probe. Confish
+// has no dedicated health endpoint, so Ping sends an authenticated HEAD request
+// to the app's logging endpoint, which validates App-ID and App-Secret without
+// writing anything. It returns nil on a 2xx response, an error matching
+// ErrUnauthorized or ErrForbidden when the credentials are rejected, and one
+// matching ErrUnreachable when the API cannot be reached.
+func (c *Client) Ping(ctx context.Context) error {
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	req, err := c.newRequest(ctx, "HEAD", c.logEndpoint(), nil)
+	if err != nil {
+		return fmt.Errorf("failed to create ping request: %w", err)
+	}
+
+	if err := c.configLimiter.wait(ctx); err != nil {
+		return fmt.Errorf("failed to ping: %w", err)
+	}
+
+	resp, err := c.do(req, true)
+	if err != nil {
+		if ctx.Err() != nil {
+			return fmt.Errorf("failed to ping: %w", err)
+		}
+		return fmt.Errorf("failed to ping: %w: %w", ErrUnreachable, err)
+	}
+	defer resp.Body.Close()
+
+	if !isSuccess(resp.StatusCode) {
+		return fmt.Errorf("failed to ping: %w", newHTTPError(resp, nil))
+	}
+
+	return nil
+}
@@ -0,0 +1,78 @@
+package confish
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimit throttles requests client-side with a token bucket
+type RateLimit struct {
+	// RequestsPerSecond is the sustained request rate. Zero or less
+	// disables the limit.
+	RequestsPerSecond float64
+
+	// Burst is how many requests may be sent at once after a quiet
+	// period. Defaults to RequestsPerSecond rounded up, and at least 1.
+	Burst int
+}
+
+// tokenBucket is a RateLimit in use. A nil bucket never blocks.
+type tokenBucket struct {
+	mu     sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+	clock  clock
+}
+
+// newTokenBucket returns a full bucket for rl, or nil when rl is unset
+func newTokenBucket(rl *RateLimit, clk clock) *tokenBucket {
+	if rl == nil || rl.RequestsPerSecond <= 0 {
+		return nil
+	}
+
+	burst := float64(rl.Burst)
+	if burst <= 0 {
+		burst = math.Max(1, math.Ceil(rl.RequestsPerSecond))
+	}
+
+	return &tokenBucket{
+		rate:   rl.RequestsPerSecond,
+		burst:  burst,
+		tokens: burst,
+		last:   clk.Now(),
+		clock:  clk,
+	}
+}
+
+// wait takes a token, blocking until one is available or ctx is done.
+// A token reserved for a wait that is cancelled is given back.
+func (b *tokenBucket) wait(ctx context.Context) error {
+	if b == nil {
+		return nil
+	}
+
+	b.mu.Lock()
+	now := b.clock.Now()
+	b.tokens = math.Min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+	delay := time.Duration(-b.tokens / b.rate * float64(time.Second))
+	b.mu.Unlock()
+
+	if delay <= 0 {
+		return nil
+	}
+
+	if err := sleepContext(ctx, b.clock, delay); err != nil {
+		b.mu.Lock()
+		b.tokens++
+		b.mu.Unlock()
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package confish
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// takeNow takes n tokens from b, failing if any of them has to be waited for
+func takeNow(t *testing.T, b *tokenBucket, clk *fakeClock, n int) {
+	t.Helper()
+	for i := 0; i < n; i++ {
+		if err := b.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+		select {
+		case d := <-clk.timers:
+			t.Fatalf("token %d waited %v, want it at once", i+1, d)
+		default:
+		}
+	}
+}
+
+// waitAsync starts b.wait(ctx) and returns the delay it sleeps for along with
+// a channel reporting its result
+func waitAsync(t *testing.T, ctx context.Context, b *tokenBucket, clk *fakeClock) (time.Duration, <-chan error) {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() { done <- b.wait(ctx) }()
+	return clk.waitTimer(t), done
+}
+
+func TestTokenBucketBurst(t *testing.T) {
+	clk := newFakeClock()
+	b := newTokenBucket(&RateLimit{RequestsPerSecond: 2, Burst: 5}, clk)
+
+	takeNow(t, b, clk, 5)
+
+	d, done := waitAsync(t, context.Background(), b, clk)
+	if d != 500*time.Millisecond {
+		t.Errorf("wait after the burst = %v, want 500ms", d)
+	}
+	clk.Advance(d)
+	if err := <-done; err != nil {
+		t.Errorf("wait() = %v", err)
+	}
+}
+
+func TestTokenBucketRefill(t *testing.T) {
+	clk := newFakeClock()
+	b := newTokenBucket(&RateLimit{RequestsPerSecond: 4, Burst: 2}, clk)
+
+	takeNow(t, b, clk, 2)
+	clk.Advance(500 * time.Millisecond)
+	takeNow(t, b, clk, 2)
+
+	// Refilling never exceeds the burst
+	clk.Advance(time.Hour)
+	takeNow(t, b, clk, 2)
+	if d, done := waitAsync(t, context.Background(), b, clk); d != 250*time.Millisecond {
+		t.Errorf("wait after refilling = %v, want 250ms", d)
+	} else {
+		clk.Advance(d)
+		<-done
+	}
+}
+
+func TestTokenBucketWaitCancelled(t *testing.T) {
+	clk := newFakeClock()
+	b := newTokenBucket(&RateLimit{RequestsPerSecond: 1, Burst: 1}, clk)
+	takeNow(t, b, clk, 1)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d, done := waitAsync(t, ctx, b, clk)
+	if d != time.Second {
+		t.Errorf("wait = %v, want 1s", d)
+	}
+	cancel()
+	if err := <-done; !errors.Is(err, context.Canceled) {
+		t.Fatalf("cancelled wait() = %v, want context.Canceled", err)
+	}
+
+	// The cancelled wait gave its token back
+	d, done = waitAsync(t, context.Background(), b, clk)
+	if d != time.Second {
+		t.Errorf("wait after a cancelled one = %v, want 1s", d)
+	}
+	clk.Advance(d)
+	<-done
+}
+
+func TestTokenBucketDefaults(t *testing.T) {
+	if b := newTokenBucket(&RateLimit{}, newFakeClock()); b != nil {
+		t.Error("bucket without a rate is not nil")
+	}
+	if err := (*tokenBucket)(nil).wait(context.Background()); err != nil {
+		t.Errorf("nil bucket wait() = %v", err)
+	}
+
+	clk := newFakeClock()
+	b := newTokenBucket(&RateLimit{RequestsPerSecond: 2.5}, clk)
+	takeNow(t, b, clk, 3)
+	if d, done := waitAsync(t, context.Background(), b, clk); d != 400*time.Millisecond {
+		t.Errorf("wait after the default burst = %v, want 400ms", d)
+	} else {
+		clk.Advance(d)
+		<-done
+	}
+}
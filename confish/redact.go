@@ -0,0 +1,85 @@
+package confish
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// redacted replaces secrets in formatted output
+const redacted = "****"
+
+// plainConfig has the fields of ConfishConfig but none of its methods, so it
+// can be formatted without recursing into String
+type plainConfig ConfishConfig
+
+// redact returns a copy of cfg with the app secret, header values and any
+// passwords in URL and ProxyURL masked
+func (cfg ConfishConfig) redact() plainConfig {
+	if cfg.AppSecret != "" {
+		cfg.AppSecret = redacted
+	}
+	cfg.URL = redactURL(cfg.URL)
+	cfg.ProxyURL = redactURL(cfg.ProxyURL)
+	cfg.Headers = redactHeader(cfg.Headers)
+	return plainConfig(cfg)
+}
+
+// redactURL masks the password of a URL with user info
+func redactURL(rawURL string) string {
+	if u, err := url.Parse(rawURL); err == nil && u.User != nil {
+		return u.Redacted()
+	}
+	return rawURL
+}
+
+// redactHeader returns a copy of h with every value masked, since any of them
+// may carry a credential such as Authorization or X-Api-Key
+func redactHeader(h http.Header) http.Header {
+	if h == nil {
+		return nil
+	}
+
+	masked := make(http.Header, len(h))
+	for name, values := range h {
+		masked[name] = make([]string, len(values))
+		for i := range values {
+			masked[name][i] = redacted
+		}
+	}
+	return masked
+}
+
+// String formats the config with its secrets masked, so printing it with %v
+// or %+v never leaks them
+func (cfg ConfishConfig) String() string {
+	return fmt.Sprintf("%+v", cfg.redact())
+}
+
+// LogValue makes slog handlers, including JSON ones, log the masked String
+func (cfg ConfishConfig) LogValue() slog.Value {
+	return slog.StringValue(cfg.String())
+}
+
+// GoString is like String for the %#v verb
+func (cfg ConfishConfig) GoString() string {
+	s := fmt.Sprintf("%#v", cfg.redact())
+	return "confish.ConfishConfig" + strings.TrimPrefix(s, "confish.plainConfig")
+}
+
+// String describes the client without its credentials
+func (c *Client) String() string {
+	return fmt.Sprintf("confish.Client{URL: %s, AppID: %s}", redactURL(c.cfg.URL), c.appID())
+}
+
+// GoString is like String for the %#v verb
+func (c *Client) GoString() string {
+	return c.String()
+}
+
+// LogValue makes slog handlers log the client as String does
+func (c *Client) LogValue() slog.Value {
+	return slog.StringValue(c.String())
+}
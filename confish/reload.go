@@ -0,0 +1,86 @@
+package confish
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+)
+
+// ConfigReloader keeps a typed config current: Load seeds it from the API
+// and Apply swaps in the values carried by each webhook. Readers call Current
+// and always see a complete value.
+type ConfigReloader[T any] struct {
+	name  string
+	store *ConfigStore[T]
+	// client is the client last passed to Load or WebhookHandler, whose
+	// decoding options Apply uses
+	client atomic.Pointer[Client]
+}
+
+// NewConfigReloader creates a reloader for the config with the given name.
+// Webhooks for other configs are ignored; an empty name accepts every config.
+// Until the first Load or Apply, Current returns the zero value of T.
+func NewConfigReloader[T any](name string) *ConfigReloader[T] {
+	var zero T
+	return &ConfigReloader[T]{name: name, store: NewConfigStore(zero)}
+}
+
+// Current returns the latest value. Treat it as read-only; it may be shared
+// with other readers.
+func (r *ConfigReloader[T]) Current() T {
+	return r.store.Load()
+}
+
+// Load fetches configID from the API and makes it the current value
+func (r *ConfigReloader[T]) Load(c *Client, configID string) error {
+	return r.LoadContext(context.Background(), c, configID)
+}
+
+// LoadContext is like Load but aborts the request when ctx is cancelled or its deadline passes
+func (r *ConfigReloader[T]) LoadContext(ctx context.Context, c *Client, configID string) error {
+	r.client.Store(c)
+
+	var v T
+	if err := c.GetConfigContext(ctx, configID, &v); err != nil {
+		return err
+	}
+	r.store.update(v)
+
+	return nil
+}
+
+// Apply makes the values of a created or updated webhook the current value,
+// applying the webhook's patch to it when the payload only carries one.
+// Deleted events and payloads for other configs leave it unchanged. Any other
+// event yields an *UnsupportedEventError. Values are decoded with the options
+// of the client last passed to Load or WebhookHandler, or with encoding/json
+// before that. Values that fail to decode or validate are rejected and the
+// current value is kept.
+func (r *ConfigReloader[T]) Apply(payload WebhookPayload) error {
+	switch payload.Event {
+	case EventConfigurationCreated, EventConfigurationUpdated:
+	case EventConfigurationDeleted:
+		return nil
+	default:
+		return &UnsupportedEventError{Event: payload.Event}
+	}
+
+	return r.apply(r.client.Load(), payload)
+}
+
+// apply decodes the values of a created or updated webhook with c and swaps
+// them in if they belong to the reloader's config
+func (r *ConfigReloader[T]) apply(c *Client, payload WebhookPayload) error {
+	if r.name != "" && payload.Configuration.Name != r.name {
+		return nil
+	}
+
+	return r.store.applyWebhook(c, payload)
+}
+
+// WebhookHandler returns an http.Handler that verifies webhooks through c and
+// applies them like Apply. Rejected values are reported to c's ErrorHandler.
+func (r *ConfigReloader[T]) WebhookHandler(c *Client) http.Handler {
+	r.client.Store(c)
+	return r.store.WebhookHandler(c, r.name)
+}
@@ -0,0 +1,43 @@
+package confish
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+)
+
+// RequestIDHeader carries the ID used to correlate a request across client
+// and server logs
+const RequestIDHeader = "X-Request-Id"
+
+// IdempotencyKeyHeader carries the key that lets the server recognise retries
+// of a log write it already accepted
+const IdempotencyKeyHeader = "Idempotency-Key"
+
+// setIdempotencyKey gives req a fresh idempotency key when IdempotencyKeys is
+// enabled. Retries clone req, so every attempt carries the same key.
+func (c *Client) setIdempotencyKey(req *http.Request) error {
+	if !c.cfg.IdempotencyKeys || req.Header.Get(IdempotencyKeyHeader) != "" {
+		return nil
+	}
+
+	key, err := newRequestID()
+	if err != nil {
+		return err
+	}
+	req.Header.Set(IdempotencyKeyHeader, key)
+	return nil
+}
+
+// newRequestID returns a random version 4 UUID
+func newRequestID() (string, error) {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return "", fmt.Errorf("failed to generate request id: %w", err)
+	}
+
+	b[6] = (b[6] & 0x0f) | 0x40
+	b[8] = (b[8] & 0x3f) | 0x80
+
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16]), nil
+}
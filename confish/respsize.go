@@ -0,0 +1,39 @@
+package confish
+
+import (
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DefaultMaxResponseBytes bounds response bodies when MaxResponseBytes is zero
+const DefaultMaxResponseBytes = 32 << 20
+
+// maxErrorBodyBytes bounds how much of an error response body is kept in an
+// HTTPError
+const maxErrorBodyBytes = 64 << 10
+
+// ErrResponseTooLarge is returned when a response body exceeds MaxResponseBytes
+var ErrResponseTooLarge = errors.New("confish: response too large")
+
+// readBody reads a response body, failing with ErrResponseTooLarge once it
+// exceeds MaxResponseBytes rather than buffering all of it
+func (c *Client) readBody(body io.Reader) ([]byte, error) {
+	limit := c.cfg.MaxResponseBytes
+	if limit < 0 {
+		return io.ReadAll(body)
+	}
+	if limit == 0 {
+		limit = DefaultMaxResponseBytes
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrResponseTooLarge, limit)
+	}
+
+	return data, nil
+}
@@ -0,0 +1,194 @@
+package confish
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// defaultRetryBaseDelay is used when retries are enabled without a base delay
+const defaultRetryBaseDelay = 100 * time.Millisecond
+
+// maxRetryDelay caps the backoff between two attempts
+const maxRetryDelay = 30 * time.Second
+
+type retryCountKey struct{}
+
+// WithRetryCount returns a context that makes the client store into n the
+// number of retries it performed for a request made with that context
+func WithRetryCount(ctx context.Context, n *int) context.Context {
+	return context.WithValue(ctx, retryCountKey{}, n)
+}
+
+func recordRetries(ctx context.Context, retries int) {
+	if n, ok := ctx.Value(retryCountKey{}).(*int); ok && n != nil {
+		*n = retries
+	}
+}
+
+// do sends req, unless the circuit breaker is open, and decodes compressed
+// responses. A 401 is retried once with a new token when a TokenSource is
+// configured. Errors mention the request ID when one was sent.
+func (c *Client) do(req *http.Request, idempotent bool) (*http.Response, error) {
+	if err := c.breaker.allow(); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.doWithRetry(req, idempotent)
+	if err == nil && resp.StatusCode == http.StatusUnauthorized {
+		resp, err = c.retryUnauthorized(req, resp, idempotent)
+	}
+	c.breaker.record(outcomeOf(req, resp, err))
+	if err != nil {
+		if id := req.Header.Get(RequestIDHeader); id != "" {
+			return nil, fmt.Errorf("request %s: %w", id, err)
+		}
+		return nil, err
+	}
+
+	if req.Header.Get("Accept-Encoding") != "" {
+		if err := decompressResponse(resp); err != nil {
+			return nil, err
+		}
+	}
+
+	return resp, nil
+}
+
+// doWithRetry sends req, retrying transient failures according to the client config.
+// Connection errors are always retryable; 429, 502, 503 and 504 responses are
+// only retried for idempotent requests. A 429 waits for the duration in its
+// Retry-After header when present. Retries are skipped once the client's
+// RetryBudget is spent, and never made for a request whose body can't be
+// sent again. A request aborted by its context fails with the
+// context's error; other transport errors go through classifyNetError.
+func (c *Client) doWithRetry(req *http.Request, idempotent bool) (*http.Response, error) {
+	ctx := req.Context()
+
+	for attempt := 0; ; attempt++ {
+		attemptReq := req
+		if attempt > 0 {
+			attemptReq = req.Clone(ctx)
+			if req.GetBody != nil {
+				body, err := req.GetBody()
+				if err != nil {
+					return nil, err
+				}
+				attemptReq.Body = body
+			}
+			if err := c.authorizer.Apply(attemptReq); err != nil {
+				recordRetries(ctx, attempt)
+				return nil, &authorizeError{err: err}
+			}
+		}
+
+		start := time.Now()
+		resp, err := c.httpClient().Do(attemptReq)
+		c.debugExchange(attemptReq, attempt, resp, err, time.Since(start))
+		if err != nil && ctx.Err() != nil {
+			recordRetries(ctx, attempt)
+			return nil, ctx.Err()
+		}
+
+		if err == nil && resp.StatusCode < 500 && resp.StatusCode != http.StatusTooManyRequests {
+			c.retryBudget.succeeded()
+		}
+
+		if attempt >= c.cfg.MaxRetries || !replayable(req) || !shouldRetry(resp, err, idempotent) || !c.retryBudget.take() {
+			recordRetries(ctx, attempt)
+			if err != nil {
+				return nil, classifyNetError(err)
+			}
+			return resp, nil
+		}
+
+		delay := c.backoff(attempt)
+		if resp != nil {
+			if resp.StatusCode == http.StatusTooManyRequests {
+				if after, ok := parseRetryAfter(resp.Header.Get("Retry-After"), c.clock.Now()); ok {
+					delay = after
+				}
+			}
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+
+		if err := sleepContext(ctx, c.clock, delay); err != nil {
+			recordRetries(ctx, attempt)
+			return nil, err
+		}
+	}
+}
+
+// replayable reports whether req's body, if any, can be sent again
+func replayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// shouldRetry reports whether a request outcome warrants another attempt
+func shouldRetry(resp *http.Response, err error, idempotent bool) bool {
+	if err != nil {
+		return true
+	}
+
+	if !idempotent {
+		return false
+	}
+
+	return retryableStatus(resp.StatusCode)
+}
+
+// retryableStatus reports whether a response status is worth retrying
+func retryableStatus(status int) bool {
+	switch status {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// backoff returns the delay before the retry following the given attempt,
+// doubling each time up to maxRetryDelay with up to 50% random jitter
+func (c *Client) backoff(attempt int) time.Duration {
+	base := c.cfg.RetryBaseDelay
+	if base <= 0 {
+		base = defaultRetryBaseDelay
+	}
+
+	delay := base << attempt
+	if delay <= 0 || delay > maxRetryDelay {
+		delay = maxRetryDelay
+	}
+
+	half := delay / 2
+	return half + rand.N(half+1)
+}
+
+// parseRetryAfter parses a Retry-After header value given either as a number
+// of seconds or as an HTTP date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	if value == "" {
+		return 0, false
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0, false
+		}
+		return time.Duration(seconds) * time.Second, true
+	}
+
+	if date, err := http.ParseTime(value); err == nil {
+		if d := date.Sub(now); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+
+	return 0, false
+}
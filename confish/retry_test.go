@@ -0,0 +1,184 @@
+package confish
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// scriptedServer answers requests with statuses in order, repeating the last
+// one, and counts the requests it got. A zero status closes the connection
+// without answering.
+func scriptedServer(t *testing.T, header http.Header, statuses ...int) (*httptest.Server, *atomic.Int32) {
+	t.Helper()
+
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := int(requests.Add(1))
+		status := statuses[min(n, len(statuses))-1]
+		if status == 0 {
+			conn, _, err := w.(http.Hijacker).Hijack()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			conn.Close()
+			return
+		}
+
+		for k, v := range header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(status)
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestRetryTransientFailures(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{"bad gateway", http.StatusBadGateway},
+		{"service unavailable", http.StatusServiceUnavailable},
+		{"gateway timeout", http.StatusGatewayTimeout},
+		{"connection error", 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, requests := scriptedServer(t, nil, tt.status, http.StatusOK)
+			clk := newFakeClock()
+			client, err := NewClientWithOptions(server.URL, "app", "secret", WithRetries(3, time.Second), withClock(clk))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stop := clk.advanceTimers()
+			var retries int
+			var result map[string]interface{}
+			err = client.GetConfigContext(WithRetryCount(context.Background(), &retries), "flags", &result)
+			stop()
+
+			if err != nil {
+				t.Fatalf("GetConfig() error = %v", err)
+			}
+			if n := requests.Load(); n != 2 || retries != 1 {
+				t.Errorf("requests = %d, retries = %d, want 2 and 1", n, retries)
+			}
+		})
+	}
+}
+
+func TestRetryDoesNotRepeatLogWrites(t *testing.T) {
+	server, requests := scriptedServer(t, nil, http.StatusServiceUnavailable, http.StatusOK)
+	clk := newFakeClock()
+	client, err := NewClientWithOptions(server.URL, "app", "secret", WithRetries(3, time.Second), withClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := clk.advanceTimers()
+	err = client.Info("hello")
+	delays := stop()
+
+	var httpErr *HTTPError
+	if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusServiceUnavailable {
+		t.Fatalf("Info() error = %v, want the 503", err)
+	}
+	if n := requests.Load(); n != 1 || len(delays) != 0 {
+		t.Errorf("requests = %d after %d backoffs, want a single request", n, len(delays))
+	}
+}
+
+func TestRetryLimit(t *testing.T) {
+	for _, maxRetries := range []int{0, 1, 3} {
+		server, requests := scriptedServer(t, nil, http.StatusServiceUnavailable)
+		clk := newFakeClock()
+		client, err := NewClientWithOptions(server.URL, "app", "secret", WithRetries(maxRetries, time.Second), withClock(clk))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		stop := clk.advanceTimers()
+		var retries int
+		var result map[string]interface{}
+		err = client.GetConfigContext(WithRetryCount(context.Background(), &retries), "flags", &result)
+		stop()
+
+		if err == nil {
+			t.Fatalf("GetConfig() with %d retries succeeded against a failing server", maxRetries)
+		}
+		if n := requests.Load(); int(n) != maxRetries+1 || retries != maxRetries {
+			t.Errorf("with %d retries: requests = %d, retry count = %d, want %d and %d", maxRetries, n, retries, maxRetries+1, maxRetries)
+		}
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	tests := []struct {
+		name  string
+		value func(now time.Time) string
+		want  time.Duration
+	}{
+		{"seconds", func(time.Time) string { return "7" }, 7 * time.Second},
+		{"HTTP date", func(now time.Time) string { return now.Add(90 * time.Second).Format(http.TimeFormat) }, 90 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clk := newFakeClock()
+			server, requests := scriptedServer(t, http.Header{"Retry-After": {tt.value(clk.Now())}}, http.StatusTooManyRequests, http.StatusOK)
+			client, err := NewClientWithOptions(server.URL, "app", "secret", WithRetries(1, time.Second), withClock(clk))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			stop := clk.advanceTimers()
+			var result map[string]interface{}
+			err = client.GetConfig("flags", &result)
+			delays := stop()
+
+			if err != nil {
+				t.Fatalf("GetConfig() error = %v", err)
+			}
+			if len(delays) != 1 || delays[0] != tt.want {
+				t.Errorf("waited %v before retrying, want %v", delays, tt.want)
+			}
+			if n := requests.Load(); n != 2 {
+				t.Errorf("requests = %d, want 2", n)
+			}
+		})
+	}
+}
+
+func TestRateLimitErrorWhenRetriesRunOut(t *testing.T) {
+	server, requests := scriptedServer(t, http.Header{"Retry-After": {"30"}}, http.StatusTooManyRequests)
+	clk := newFakeClock()
+	client, err := NewClientWithOptions(server.URL, "app", "secret", WithRetries(2, time.Second), withClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stop := clk.advanceTimers()
+	var result map[string]interface{}
+	err = client.GetConfig("flags", &result)
+	stop()
+
+	var rateErr *RateLimitError
+	if !errors.As(err, &rateErr) {
+		t.Fatalf("GetConfig() error = %v, want a *RateLimitError", err)
+	}
+	if rateErr.RetryAfter != 30*time.Second || rateErr.StatusCode != http.StatusTooManyRequests {
+		t.Errorf("RateLimitError = %+v, want status 429 and RetryAfter 30s", rateErr)
+	}
+	if n := requests.Load(); n != 3 {
+		t.Errorf("requests = %d, want 3", n)
+	}
+}
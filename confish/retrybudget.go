@@ -0,0 +1,85 @@
+package confish
+
+import "sync"
+
+// RetryBudget limits retries across all requests of a client, so that during
+// a partial outage goroutines retrying independently don't multiply the load
+// on the API. Each retry spends RetryCost tokens and each successful request
+// earns SuccessRefund back, up to Capacity. When too few tokens are left,
+// failed requests return their error at once instead of retrying.
+type RetryBudget struct {
+	// Capacity is the number of tokens the budget starts with and can hold.
+	// Defaults to 500.
+	Capacity int
+
+	// RetryCost is the number of tokens a retry spends. Defaults to 5.
+	RetryCost int
+
+	// SuccessRefund is the number of tokens a successful request earns.
+	// Defaults to 1.
+	SuccessRefund int
+}
+
+const (
+	defaultRetryBudgetCapacity = 500
+	defaultRetryCost           = 5
+	defaultSuccessRefund       = 1
+)
+
+// retryBudget is a RetryBudget in use. A nil budget allows every retry.
+type retryBudget struct {
+	mu       sync.Mutex
+	tokens   int
+	capacity int
+	cost     int
+	refund   int
+}
+
+func newRetryBudget(cfg *RetryBudget) *retryBudget {
+	if cfg == nil {
+		return nil
+	}
+
+	b := &retryBudget{capacity: cfg.Capacity, cost: cfg.RetryCost, refund: cfg.SuccessRefund}
+	if b.capacity <= 0 {
+		b.capacity = defaultRetryBudgetCapacity
+	}
+	if b.cost <= 0 {
+		b.cost = defaultRetryCost
+	}
+	if b.refund <= 0 {
+		b.refund = defaultSuccessRefund
+	}
+	b.tokens = b.capacity
+
+	return b
+}
+
+// take spends the cost of one retry, reporting false when the budget can't
+// afford it
+func (b *retryBudget) take() bool {
+	if b == nil {
+		return true
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.tokens < b.cost {
+		return false
+	}
+	b.tokens -= b.cost
+	return true
+}
+
+// succeeded earns the refund for a successful request
+func (b *retryBudget) succeeded() {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.tokens = min(b.capacity, b.tokens+b.refund)
+}
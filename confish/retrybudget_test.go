@@ -0,0 +1,78 @@
+package confish
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRetryBudget(t *testing.T) {
+	var healthy atomic.Bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !healthy.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{}`))
+	}))
+	defer server.Close()
+
+	clk := newFakeClock()
+	client, err := NewClientWithOptions(server.URL, "app", "secret",
+		WithRetries(5, time.Second),
+		WithRetryBudget(RetryBudget{Capacity: 10, RetryCost: 5, SuccessRefund: 5}),
+		withClock(clk),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// get fetches a config and returns the number of retries it took
+	get := func() int {
+		t.Helper()
+		stop := clk.advanceTimers()
+		defer stop()
+
+		var retries int
+		var result map[string]interface{}
+		client.GetConfigContext(WithRetryCount(context.Background(), &retries), "flags", &result)
+		return retries
+	}
+
+	if n := get(); n != 2 {
+		t.Errorf("retries with a full budget = %d, want 2", n)
+	}
+	if n := get(); n != 0 {
+		t.Errorf("retries with a spent budget = %d, want 0", n)
+	}
+
+	healthy.Store(true)
+	if n := get(); n != 0 {
+		t.Errorf("retries of a successful request = %d, want 0", n)
+	}
+
+	healthy.Store(false)
+	if n := get(); n != 1 {
+		t.Errorf("retries after one success = %d, want 1", n)
+	}
+	if n := get(); n != 0 {
+		t.Errorf("retries once the refund is spent = %d, want 0", n)
+	}
+}
+
+func TestRetryBudgetDefaults(t *testing.T) {
+	b := newRetryBudget(&RetryBudget{})
+	if b.capacity != 500 || b.cost != 5 || b.refund != 1 || b.tokens != 500 {
+		t.Errorf("default budget = %+v", b)
+	}
+
+	var unlimited *retryBudget
+	for i := 0; i < 1000; i++ {
+		if !unlimited.take() {
+			t.Fatal("nil budget refused a retry")
+		}
+	}
+}
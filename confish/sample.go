@@ -0,0 +1,38 @@
+package confish
+
+import "math/rand/v2"
+
+// copySampleRate copies the configured rates so later changes to the caller's
+// map don't race with log calls
+func copySampleRate(rates map[LogLevel]float64) map[LogLevel]float64 {
+	if len(rates) == 0 {
+		return nil
+	}
+
+	copied := make(map[LogLevel]float64, len(rates))
+	for level, rate := range rates {
+		copied[level] = rate
+	}
+	return copied
+}
+
+// sampled decides whether a message at level is sent, counting the ones that
+// are dropped
+func (c *Client) sampled(level LogLevel) bool {
+	rate, ok := c.sampleRate[level]
+	if !ok || rate >= 1 {
+		return true
+	}
+
+	if rate > 0 && rand.Float64() < rate {
+		return true
+	}
+
+	c.sampledOut.Add(1)
+	return false
+}
+
+// SampledOut returns the number of log messages dropped by sampling so far
+func (c *Client) SampledOut() uint64 {
+	return c.sampledOut.Load()
+}
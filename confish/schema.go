@@ -0,0 +1,198 @@
+package confish
+
+import (
+	"encoding"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// schemaDialect is the JSON Schema version GenerateSchema emits
+const schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+// jsonSchema is the subset of JSON Schema GenerateSchema emits
+type jsonSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Format               string                 `json:"format,omitempty"`
+	Properties           map[string]*jsonSchema `json:"properties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *jsonSchema            `json:"items,omitempty"`
+	AdditionalProperties *jsonSchema            `json:"additionalProperties,omitempty"`
+	Default              json.RawMessage        `json:"default,omitempty"`
+}
+
+var (
+	timeType          = reflect.TypeOf(time.Time{})
+	numberType        = reflect.TypeOf(json.Number(""))
+	rawMessageType    = reflect.TypeOf(json.RawMessage(nil))
+	jsonMarshalerType = reflect.TypeOf((*json.Marshaler)(nil)).Elem()
+	textMarshalerType = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+)
+
+// GenerateSchema returns a JSON Schema describing how v, typically a config
+// struct or a pointer to one, is encoded as JSON, e.g. for server-side
+// validation. Struct fields are named after their json tags, fields tagged
+// "-" are left out and fields without omitempty are required. A valid
+// `confish:"default=..."` tag becomes the field's default, and the field is
+// then not required. Embedded structs contribute their fields. Types with their own MarshalText are
+// strings, time.Time has the date-time format, and other types with their
+// own MarshalJSON, interfaces and recursive references accept any value.
+func GenerateSchema(v interface{}) ([]byte, error) {
+	if v == nil {
+		return nil, errors.New("cannot generate a schema for nil")
+	}
+
+	schema := schemaFor(reflect.TypeOf(v), map[reflect.Type]bool{})
+	schema.Schema = schemaDialect
+
+	return json.MarshalIndent(schema, "", "  ")
+}
+
+// schemaFor returns the schema of t. Types in visiting are being described
+// further up, so meeting them again means recursion.
+func schemaFor(t reflect.Type, visiting map[reflect.Type]bool) *jsonSchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &jsonSchema{Type: "string", Format: "date-time"}
+	case t == numberType:
+		return &jsonSchema{Type: "number"}
+	case implements(t, textMarshalerType):
+		return &jsonSchema{Type: "string"}
+	case t == rawMessageType, implements(t, jsonMarshalerType):
+		return &jsonSchema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &jsonSchema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return &jsonSchema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		return &jsonSchema{Type: "number"}
+	case reflect.String:
+		return &jsonSchema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Uint8 {
+			// encoding/json writes []byte as a base64 string
+			return &jsonSchema{Type: "string", Format: "byte"}
+		}
+		return &jsonSchema{Type: "array", Items: schemaFor(t.Elem(), visiting)}
+	case reflect.Map:
+		return &jsonSchema{Type: "object", AdditionalProperties: schemaFor(t.Elem(), visiting)}
+	case reflect.Struct:
+		if visiting[t] {
+			return &jsonSchema{}
+		}
+		visiting[t] = true
+		defer delete(visiting, t)
+
+		schema := &jsonSchema{Type: "object", Properties: map[string]*jsonSchema{}}
+		addStructFields(schema, t, visiting)
+		return schema
+	default:
+		return &jsonSchema{}
+	}
+}
+
+// addStructFields adds the encoded fields of struct type t to schema.
+// Fields of the outer struct win over those of embedded ones.
+func addStructFields(schema *jsonSchema, t reflect.Type, visiting map[reflect.Type]bool) {
+	var embedded []reflect.Type
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, opts, _ := strings.Cut(tag, ",")
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			embedded = append(embedded, fieldType)
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+
+		fieldSchema := schemaFor(field.Type, visiting)
+		if hasTagOption(opts, "string") {
+			fieldSchema = &jsonSchema{Type: "string"}
+		}
+		required := !hasTagOption(opts, "omitempty")
+		if def, ok := schemaDefault(field); ok {
+			fieldSchema.Default = def
+			required = false
+		}
+		schema.Properties[name] = fieldSchema
+		if required {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+
+	for _, e := range embedded {
+		inner := &jsonSchema{Properties: map[string]*jsonSchema{}}
+		addStructFields(inner, e, visiting)
+		for _, name := range inner.Required {
+			if _, ok := schema.Properties[name]; !ok {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+		for name, s := range inner.Properties {
+			if _, ok := schema.Properties[name]; !ok {
+				schema.Properties[name] = s
+			}
+		}
+	}
+}
+
+// schemaDefault returns the JSON encoding of field's confish default, if it
+// has one that parses
+func schemaDefault(field reflect.StructField) (json.RawMessage, bool) {
+	tag, ok := strings.CutPrefix(field.Tag.Get("confish"), defaultTagPrefix)
+	if !ok {
+		return nil, false
+	}
+
+	v := reflect.New(field.Type).Elem()
+	if err := setDefault(v, tag); err != nil {
+		return nil, false
+	}
+	def, err := json.Marshal(v.Interface())
+	if err != nil {
+		return nil, false
+	}
+	return def, true
+}
+
+// implements reports whether t or a pointer to t implements iface
+func implements(t, iface reflect.Type) bool {
+	return t.Implements(iface) || reflect.PointerTo(t).Implements(iface)
+}
+
+func hasTagOption(opts, option string) bool {
+	for opts != "" {
+		var opt string
+		opt, opts, _ = strings.Cut(opts, ",")
+		if opt == option {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,134 @@
+package confish_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"testing"
+
+	"github.com/bravilogy/confish-go/confish"
+)
+
+// schemaLevel is encoded through MarshalText
+type schemaLevel int
+
+func (l schemaLevel) MarshalText() ([]byte, error) { return []byte("level"), nil }
+
+type schemaBase struct {
+	ID   string `json:"id"`
+	Name string `json:"base_name"`
+}
+
+type schemaInner struct {
+	Host string `json:"host"`
+	Port int    `json:"port,omitempty"`
+}
+
+type schemaConfig struct {
+	schemaBase
+	ID      string           `json:"id,omitempty"`
+	Secret  string           `json:"-"`
+	Tags    []string         `json:"tags,omitempty"`
+	Blob    []byte           `json:"blob"`
+	Level   schemaLevel      `json:"level"`
+	Timeout confish.Duration `json:"timeout" confish:"default=5s"`
+	Retries int              `json:"retries" confish:"default=3"`
+	Inner   schemaInner      `json:"inner"`
+	Next    *schemaConfig    `json:"next,omitempty"`
+	hidden  string
+}
+
+// generateSchema returns the decoded schema of v
+func generateSchema(t *testing.T, v interface{}) map[string]interface{} {
+	t.Helper()
+
+	data, err := confish.GenerateSchema(v)
+	if err != nil {
+		t.Fatalf("GenerateSchema() error = %v", err)
+	}
+	var schema map[string]interface{}
+	if err := json.Unmarshal(data, &schema); err != nil {
+		t.Fatalf("GenerateSchema() returned invalid JSON: %v\n%s", err, data)
+	}
+	return schema
+}
+
+// required returns the sorted required properties of schema
+func required(schema map[string]interface{}) []string {
+	var names []string
+	list, _ := schema["required"].([]interface{})
+	for _, name := range list {
+		names = append(names, name.(string))
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestGenerateSchema(t *testing.T) {
+	schema := generateSchema(t, &schemaConfig{})
+	if schema["$schema"] != "https://json-schema.org/draft/2020-12/schema" || schema["type"] != "object" {
+		t.Errorf("root = %v, want a 2020-12 object schema", schema)
+	}
+
+	props := schema["properties"].(map[string]interface{})
+	var names []string
+	for name := range props {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	wantNames := []string{"base_name", "blob", "id", "inner", "level", "next", "retries", "tags", "timeout"}
+	if !reflect.DeepEqual(names, wantNames) {
+		t.Errorf("properties = %q, want %q", names, wantNames)
+	}
+
+	wantRequired := []string{"base_name", "blob", "inner", "level"}
+	if got := required(schema); !reflect.DeepEqual(got, wantRequired) {
+		t.Errorf("required = %q, want %q", got, wantRequired)
+	}
+
+	tests := []struct {
+		property string
+		want     map[string]interface{}
+	}{
+		{"blob", map[string]interface{}{"type": "string", "format": "byte"}},
+		{"level", map[string]interface{}{"type": "string"}},
+		{"tags", map[string]interface{}{"type": "array", "items": map[string]interface{}{"type": "string"}}},
+		{"timeout", map[string]interface{}{"type": "string", "default": "5s"}},
+		{"retries", map[string]interface{}{"type": "integer", "default": float64(3)}},
+		{"id", map[string]interface{}{"type": "string"}},
+	}
+	for _, tt := range tests {
+		if got := props[tt.property]; !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("property %s = %v, want %v", tt.property, got, tt.want)
+		}
+	}
+
+	inner := props["inner"].(map[string]interface{})
+	if got := required(inner); !reflect.DeepEqual(got, []string{"host"}) {
+		t.Errorf("inner required = %q, want host only", got)
+	}
+
+	// The recursive reference accepts any value instead of recursing forever
+	if next := props["next"]; !reflect.DeepEqual(next, map[string]interface{}{}) {
+		t.Errorf("recursive property next = %v, want {}", next)
+	}
+}
+
+type schemaTree struct {
+	Value    int          `json:"value"`
+	Children []schemaTree `json:"children"`
+}
+
+func TestGenerateSchemaRecursiveSlice(t *testing.T) {
+	schema := generateSchema(t, schemaTree{})
+	children := schema["properties"].(map[string]interface{})["children"].(map[string]interface{})
+	if !reflect.DeepEqual(children, map[string]interface{}{"type": "array", "items": map[string]interface{}{}}) {
+		t.Errorf("children = %v, want an array of any value", children)
+	}
+}
+
+func TestGenerateSchemaNil(t *testing.T) {
+	if _, err := confish.GenerateSchema(nil); err == nil {
+		t.Error("GenerateSchema(nil) succeeded")
+	}
+}
@@ -0,0 +1,89 @@
+package confish
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// shutdownFlushTimeout bounds how long InstallShutdownFlush waits for pending
+// log entries to be sent
+const shutdownFlushTimeout = 10 * time.Second
+
+// shutdownHook is the signal handler installed by InstallShutdownFlush
+type shutdownHook struct {
+	once    sync.Once
+	signals chan os.Signal
+	stop    chan struct{}
+	cleanup func()
+}
+
+// InstallShutdownFlush closes the client, sending log entries queued by
+// LogAsync or buffered for batching, when the process receives one of
+// signals, SIGINT and SIGTERM by default. Closing waits at most 10s. The
+// signal is then raised again so the process ends as it would have without
+// the handler; programs that handle the signal themselves with
+// signal.Notify receive it as usual, and a second time after the flush.
+//
+// It returns a function that removes the handler, safe to call more than
+// once. Calling InstallShutdownFlush again before that returns the same
+// function without installing a second handler.
+func (c *Client) InstallShutdownFlush(signals ...os.Signal) (cleanup func()) {
+	c.shutdownMu.Lock()
+	defer c.shutdownMu.Unlock()
+
+	if c.shutdown != nil {
+		return c.shutdown.cleanup
+	}
+
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+
+	h := &shutdownHook{
+		signals: make(chan os.Signal, 1),
+		stop:    make(chan struct{}),
+	}
+	h.cleanup = func() {
+		c.shutdownMu.Lock()
+		if c.shutdown == h {
+			c.shutdown = nil
+		}
+		c.shutdownMu.Unlock()
+
+		h.once.Do(func() {
+			signal.Stop(h.signals)
+			close(h.stop)
+		})
+	}
+	signal.Notify(h.signals, signals...)
+	go c.awaitShutdown(h)
+
+	c.shutdown = h
+	return h.cleanup
+}
+
+// awaitShutdown closes the client on the first signal h receives, then
+// re-raises it
+func (c *Client) awaitShutdown(h *shutdownHook) {
+	var sig os.Signal
+	select {
+	case sig = <-h.signals:
+	case <-h.stop:
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownFlushTimeout)
+	if err := c.Close(ctx); err != nil {
+		c.handleError(err)
+	}
+	cancel()
+
+	h.cleanup()
+	if p, err := os.FindProcess(os.Getpid()); err == nil {
+		_ = p.Signal(sig)
+	}
+}
@@ -0,0 +1,127 @@
+package confish
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"sync"
+)
+
+// configFetch is a fetch of a config in progress. waiters and cancel are
+// guarded by the fetchGroup's mutex.
+type configFetch struct {
+	done chan struct{}
+	body []byte
+	meta ConfigMeta
+	err  error
+
+	waiters int
+	cancel  context.CancelFunc
+}
+
+// fetchGroup runs at most one fetch per config ID at a time. Callers
+// arriving while a fetch is in progress wait for it and share its result, so
+// an expiring cache entry doesn't send a burst of identical requests.
+type fetchGroup struct {
+	mu      sync.Mutex
+	fetches map[string]*configFetch
+}
+
+func newFetchGroup() *fetchGroup {
+	return &fetchGroup{fetches: make(map[string]*configFetch)}
+}
+
+// join returns the fetch in progress for configID, starting one with fetch
+// if there is none, and registers the caller as waiting for it. Every join
+// must be followed by a leave. The fetch outlives the ctx of the caller that
+// started it, as other callers may be waiting for it, but it is cancelled
+// once every waiter has left. store is called with a successful result
+// unless forget was called for configID meanwhile.
+func (g *fetchGroup) join(ctx context.Context, configID string, fetch func(context.Context) ([]byte, ConfigMeta, error), store func([]byte, ConfigMeta)) *configFetch {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	f := g.fetches[configID]
+	if f == nil {
+		fetchCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+		f = &configFetch{done: make(chan struct{}), cancel: cancel}
+		g.fetches[configID] = f
+		go g.run(fetchCtx, configID, f, fetch, store)
+	}
+	f.waiters++
+	return f
+}
+
+// leave unregisters a caller of join. When it was the last one waiting for a
+// fetch still in progress, the fetch is cancelled and detached so that later
+// callers start a new one.
+func (g *fetchGroup) leave(configID string, f *configFetch) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	f.waiters--
+	if f.waiters > 0 {
+		return
+	}
+
+	select {
+	case <-f.done:
+	default:
+		if g.fetches[configID] == f {
+			delete(g.fetches, configID)
+		}
+		f.cancel()
+	}
+}
+
+func (g *fetchGroup) run(ctx context.Context, configID string, f *configFetch, fetch func(context.Context) ([]byte, ConfigMeta, error), store func([]byte, ConfigMeta)) {
+	defer f.cancel()
+	f.body, f.meta, f.err = fetch(ctx)
+
+	g.mu.Lock()
+	if g.fetches[configID] == f {
+		delete(g.fetches, configID)
+		if f.err == nil {
+			store(f.body, f.meta)
+		}
+	}
+	g.mu.Unlock()
+
+	close(f.done)
+}
+
+// forget detaches the fetch in progress for configID, if any, so that later
+// callers start a new one and its result is not stored
+func (g *fetchGroup) forget(configID string) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	delete(g.fetches, configID)
+}
+
+// fetchConfigShared is like fetchConfig but shares the request with
+// concurrent callers for the same config, unless DisableSingleflight is set.
+// store is called with the result of a successful fetch, before any waiting
+// caller returns. A caller whose ctx is done stops waiting and gets its
+// error; the request is cancelled when no caller waits for it anymore.
+func (c *Client) fetchConfigShared(ctx context.Context, configID string, store func([]byte, ConfigMeta)) ([]byte, ConfigMeta, error) {
+	if c.cfg.DisableSingleflight {
+		body, meta, err := c.fetchConfig(ctx, configID)
+		if err == nil {
+			store(body, meta)
+		}
+		return body, meta, err
+	}
+
+	f := c.flights.join(ctx, configID, func(ctx context.Context) ([]byte, ConfigMeta, error) {
+		return c.fetchConfig(ctx, configID)
+	}, store)
+	defer c.flights.leave(configID, f)
+
+	select {
+	case <-f.done:
+		return bytes.Clone(f.body), f.meta, f.err
+	case <-ctx.Done():
+		return nil, ConfigMeta{}, c.configError(configID, fmt.Errorf("failed to fetch config: %w", ctx.Err()))
+	}
+}
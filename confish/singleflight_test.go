@@ -0,0 +1,82 @@
+package confish_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bravilogy/confish-go/confish"
+)
+
+func TestGetConfigCachedSharesFetch(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		w.Write([]byte(`{"enabled":true}`))
+	}))
+	defer server.Close()
+
+	client, err := confish.NewClientWithOptions(server.URL, "app", "secret", confish.WithCacheTTL(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var result struct{ Enabled bool }
+			if err := client.GetConfigCached("flags", &result); err != nil || !result.Enabled {
+				t.Errorf("GetConfigCached() = %v, %+v", err, result)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := requests.Load(); n != 1 {
+		t.Errorf("server got %d requests, want 1", n)
+	}
+}
+
+func TestGetConfigCachedCancelsAbandonedFetch(t *testing.T) {
+	var requests atomic.Int32
+	cancelled := make(chan struct{}, 10)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		<-r.Context().Done()
+		cancelled <- struct{}{}
+	}))
+	defer server.Close()
+
+	client, err := confish.NewClientWithOptions(server.URL, "app", "secret", confish.WithCacheTTL(time.Minute))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i := 0; i < 3; i++ {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		var result map[string]interface{}
+		err := client.GetConfigCachedContext(ctx, "flags", &result)
+		cancel()
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("call %d: err = %v, want context.DeadlineExceeded", i, err)
+		}
+
+		select {
+		case <-cancelled:
+		case <-time.After(5 * time.Second):
+			t.Fatalf("call %d: upstream request was not cancelled", i)
+		}
+	}
+
+	if n := requests.Load(); n != 3 {
+		t.Errorf("server got %d requests, want 3", n)
+	}
+}
@@ -0,0 +1,192 @@
+package confish
+
+import (
+	"context"
+	"log/slog"
+	"runtime"
+)
+
+// slogHandler is a slog.Handler that forwards records to Confish
+type slogHandler struct {
+	client *Client
+	opts   slog.HandlerOptions
+	goas   []groupOrAttrs
+}
+
+// slogGroup holds the attributes of a slog group as nested log fields
+type slogGroup map[string]interface{}
+
+// groupOrAttrs is either a group name or attributes added with WithGroup/WithAttrs
+type groupOrAttrs struct {
+	group string
+	attrs []slog.Attr
+}
+
+// NewSlogHandler returns a slog.Handler that sends records through client.
+// Record attributes become structured log fields, with groups turned into
+// nested objects. opts may be nil; records below opts.Level (Info by default)
+// are discarded.
+func NewSlogHandler(client *Client, opts *slog.HandlerOptions) slog.Handler {
+	h := &slogHandler{client: client}
+	if opts != nil {
+		h.opts = *opts
+	}
+
+	return h
+}
+
+// Enabled reports whether records at level are sent, taking both the handler
+// level and the client's minimum level into account
+func (h *slogHandler) Enabled(_ context.Context, level slog.Level) bool {
+	minLevel := slog.LevelInfo
+	if h.opts.Level != nil {
+		minLevel = h.opts.Level.Level()
+	}
+
+	return level >= minLevel && h.client.levelEnabled(slogLevel(level))
+}
+
+// Handle sends the record to Confish
+func (h *slogHandler) Handle(ctx context.Context, r slog.Record) error {
+	fields := slogGroup{}
+	current := fields
+	var groups []string
+
+	if h.opts.AddSource && r.PC != 0 {
+		frames := runtime.CallersFrames([]uintptr{r.PC})
+		frame, _ := frames.Next()
+		h.addAttr(current, groups, slog.Any(slog.SourceKey, &slog.Source{
+			Function: frame.Function,
+			File:     frame.File,
+			Line:     frame.Line,
+		}))
+	}
+
+	for _, goa := range h.goas {
+		if goa.group != "" {
+			nested := slogGroup{}
+			current[goa.group] = nested
+			current = nested
+			groups = append(groups, goa.group)
+			continue
+		}
+		for _, a := range goa.attrs {
+			h.addAttr(current, groups, a)
+		}
+	}
+
+	r.Attrs(func(a slog.Attr) bool {
+		h.addAttr(current, groups, a)
+		return true
+	})
+
+	pruneEmptyGroups(fields)
+	if len(fields) == 0 {
+		fields = nil
+	}
+
+	return h.client.LogFieldsContext(ctx, slogLevel(r.Level), r.Message, fields)
+}
+
+// WithAttrs returns a handler that adds attrs to every record
+func (h *slogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	if len(attrs) == 0 {
+		return h
+	}
+
+	return h.withGroupOrAttrs(groupOrAttrs{attrs: attrs})
+}
+
+// WithGroup returns a handler that nests subsequent attributes under name
+func (h *slogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+
+	return h.withGroupOrAttrs(groupOrAttrs{group: name})
+}
+
+func (h *slogHandler) withGroupOrAttrs(goa groupOrAttrs) *slogHandler {
+	h2 := *h
+	h2.goas = make([]groupOrAttrs, len(h.goas)+1)
+	copy(h2.goas, h.goas)
+	h2.goas[len(h2.goas)-1] = goa
+
+	return &h2
+}
+
+// addAttr stores a resolved attribute in fields, following the slog.Handler rules
+func (h *slogHandler) addAttr(fields slogGroup, groups []string, a slog.Attr) {
+	a.Value = a.Value.Resolve()
+	if a.Value.Kind() != slog.KindGroup && h.opts.ReplaceAttr != nil {
+		a = h.opts.ReplaceAttr(groups, a)
+		a.Value = a.Value.Resolve()
+	}
+
+	if a.Equal(slog.Attr{}) {
+		return
+	}
+
+	if a.Value.Kind() == slog.KindGroup {
+		attrs := a.Value.Group()
+		if len(attrs) == 0 {
+			return
+		}
+
+		target := fields
+		if a.Key != "" {
+			target = slogGroup{}
+			fields[a.Key] = target
+			groups = append(groups[:len(groups):len(groups)], a.Key)
+		}
+		for _, ga := range attrs {
+			h.addAttr(target, groups, ga)
+		}
+		return
+	}
+
+	fields[a.Key] = slogValue(a.Value)
+}
+
+// slogValue converts a resolved slog.Value into something that marshals sensibly to JSON
+func slogValue(v slog.Value) interface{} {
+	if v.Kind() != slog.KindAny {
+		return v.Any()
+	}
+
+	if err, ok := v.Any().(error); ok {
+		return err.Error()
+	}
+
+	return v.Any()
+}
+
+// pruneEmptyGroups removes nested groups that ended up without attributes
+func pruneEmptyGroups(fields slogGroup) {
+	for k, v := range fields {
+		nested, ok := v.(slogGroup)
+		if !ok {
+			continue
+		}
+		pruneEmptyGroups(nested)
+		if len(nested) == 0 {
+			delete(fields, k)
+		}
+	}
+}
+
+// slogLevel maps a slog level onto the closest Confish log level
+func slogLevel(level slog.Level) LogLevel {
+	switch {
+	case level < slog.LevelInfo:
+		return LogLevelDebug
+	case level < slog.LevelWarn:
+		return LogLevelInfo
+	case level < slog.LevelError:
+		return LogLevelWarn
+	case level < slog.LevelError+4:
+		return LogLevelError
+	default:
+		return LogLevelCritical
+	}
+}
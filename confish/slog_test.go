@@ -0,0 +1,141 @@
+package confish_test
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"reflect"
+	"testing"
+	"testing/slogtest"
+	"time"
+
+	"github.com/bravilogy/confish-go/confish"
+	"github.com/bravilogy/confish-go/confish/confishtest"
+)
+
+// slogServer returns a test server and a client logging to it
+func slogServer(t *testing.T) (*confishtest.Server, *confish.Client) {
+	t.Helper()
+
+	server := confishtest.NewServer()
+	t.Cleanup(server.Close)
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return server, client
+}
+
+// jsonFields round-trips fields through JSON, as the server sees them
+func jsonFields(t *testing.T, fields map[string]interface{}) map[string]interface{} {
+	t.Helper()
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var decoded map[string]interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatal(err)
+	}
+	return decoded
+}
+
+func TestSlogHandlerConformance(t *testing.T) {
+	var server *confishtest.Server
+	slogtest.Run(t, func(t *testing.T) slog.Handler {
+		if t.Name() == "TestSlogHandlerConformance/zero-time" {
+			t.Skip("Confish stamps every entry when it arrives, so entries always have a time")
+		}
+		var client *confish.Client
+		server, client = slogServer(t)
+		return confish.NewSlogHandler(client, nil)
+	}, func(t *testing.T) map[string]any {
+		logs := server.LoggedPayloads()
+		if len(logs) != 1 {
+			t.Fatalf("logged payloads = %+v, want one", logs)
+		}
+
+		m := jsonFields(t, logs[0].Fields)
+		if m == nil {
+			m = map[string]any{}
+		}
+		m[slog.TimeKey] = time.Now()
+		m[slog.LevelKey] = logs[0].Level
+		m[slog.MessageKey] = logs[0].Message
+		return m
+	})
+}
+
+func TestSlogHandlerGroupsAndAttrs(t *testing.T) {
+	server, client := slogServer(t)
+
+	logger := slog.New(confish.NewSlogHandler(client, nil)).
+		With("a", 1).
+		WithGroup("g").
+		With("b", 2).
+		WithGroup("h")
+	logger.Info("nested", "c", 3, slog.Group("i", "d", 4), slog.Group("empty"))
+
+	logs := server.LoggedPayloads()
+	if len(logs) != 1 {
+		t.Fatalf("logged payloads = %+v, want one", logs)
+	}
+	want := map[string]interface{}{
+		"a": float64(1),
+		"g": map[string]interface{}{
+			"b": float64(2),
+			"h": map[string]interface{}{
+				"c": float64(3),
+				"i": map[string]interface{}{"d": float64(4)},
+			},
+		},
+	}
+	if got := jsonFields(t, logs[0].Fields); !reflect.DeepEqual(got, want) {
+		t.Errorf("fields = %v, want %v", got, want)
+	}
+}
+
+func TestSlogHandlerLevels(t *testing.T) {
+	tests := []struct {
+		level slog.Level
+		want  confish.LogLevel
+	}{
+		{slog.LevelDebug - 4, confish.LogLevelDebug},
+		{slog.LevelDebug, confish.LogLevelDebug},
+		{slog.LevelInfo, confish.LogLevelInfo},
+		{slog.LevelInfo + 2, confish.LogLevelInfo},
+		{slog.LevelWarn, confish.LogLevelWarn},
+		{slog.LevelError, confish.LogLevelError},
+		{slog.LevelError + 3, confish.LogLevelError},
+		{slog.LevelError + 4, confish.LogLevelCritical},
+	}
+
+	server, client := slogServer(t)
+	logger := slog.New(confish.NewSlogHandler(client, &slog.HandlerOptions{Level: slog.LevelDebug - 4}))
+	for _, tt := range tests {
+		logger.Log(context.Background(), tt.level, tt.level.String())
+	}
+
+	logs := server.LoggedPayloads()
+	if len(logs) != len(tests) {
+		t.Fatalf("logged %d entries, want %d", len(logs), len(tests))
+	}
+	for i, tt := range tests {
+		if logs[i].Level != tt.want {
+			t.Errorf("slog level %v = %q, want %q", tt.level, logs[i].Level, tt.want)
+		}
+	}
+}
+
+func TestSlogHandlerMinimumLevel(t *testing.T) {
+	server, client := slogServer(t)
+
+	logger := slog.New(confish.NewSlogHandler(client, nil))
+	logger.Debug("dropped")
+	logger.Info("kept")
+
+	if logs := server.LoggedPayloads(); len(logs) != 1 || logs[0].Message != "kept" {
+		t.Errorf("logged payloads = %+v, want only the info entry", logs)
+	}
+}
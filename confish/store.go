@@ -0,0 +1,79 @@
+package confish
+
+import (
+	"net/http"
+	"sync/atomic"
+)
+
+// ConfigStore holds the latest decoded value of a config and lets any number
+// of goroutines read it while webhooks replace it. Readers and writers never
+// block each other: each update swaps in a new value atomically.
+type ConfigStore[T any] struct {
+	current atomic.Pointer[T]
+}
+
+// NewConfigStore creates a store holding initial
+func NewConfigStore[T any](initial T) *ConfigStore[T] {
+	s := &ConfigStore[T]{}
+	s.update(initial)
+	return s
+}
+
+// Load returns the current value. Treat it as read-only; it may be shared
+// with other readers.
+func (s *ConfigStore[T]) Load() T {
+	return *s.current.Load()
+}
+
+func (s *ConfigStore[T]) update(v T) {
+	s.current.Store(&v)
+}
+
+// WebhookHandler returns an http.Handler that receives webhooks through c and
+// replaces the stored value whenever the config with the given name is
+// created or updated. An empty name accepts every config. A payload that
+// only carries a patch has it applied to the stored value. Values get their
+// default tags applied and are validated like a fetched config; values that
+// fail to decode or validate leave the store unchanged and are reported to
+// c's ErrorHandler.
+func (s *ConfigStore[T]) WebhookHandler(c *Client, name string) http.Handler {
+	return c.webhookHandler(func(payload WebhookPayload) error {
+		switch payload.Event {
+		case EventConfigurationCreated, EventConfigurationUpdated:
+		case EventConfigurationDeleted:
+			return nil
+		default:
+			return &UnsupportedEventError{Event: payload.Event}
+		}
+
+		if name != "" && payload.Configuration.Name != name {
+			return nil
+		}
+
+		if err := s.applyWebhook(c, payload); err != nil {
+			c.handleError(err)
+		}
+		return nil
+	})
+}
+
+// applyWebhook decodes the values of a created or updated webhook with c and
+// swaps them in. A patch is applied to the current value, and again to the
+// new one if another update replaced it meanwhile.
+func (s *ConfigStore[T]) applyWebhook(c *Client, payload WebhookPayload) error {
+	for {
+		current := s.current.Load()
+		values, err := webhookValues(payload, *current)
+		if err != nil {
+			return err
+		}
+
+		var v T
+		if err := c.decodeValues(values, &v); err != nil {
+			return err
+		}
+		if s.current.CompareAndSwap(current, &v) {
+			return nil
+		}
+	}
+}
@@ -0,0 +1,288 @@
+package confish
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// defaultStreamBufferSize bounds the entries a StreamLogger holds while
+// disconnected
+const defaultStreamBufferSize = 1000
+
+// maxPendingAttempts bounds how many connections an entry whose write failed
+// is resent on before it is dropped
+const maxPendingAttempts = 3
+
+// ErrStreamClosed is returned by StreamLogger.Write after Close
+var ErrStreamClosed = errors.New("confish: log stream closed")
+
+// errStreamEnded closes the request body once the server has answered, so
+// pending writes fail instead of blocking
+var errStreamEnded = errors.New("log stream ended by server")
+
+// StreamLogger sends log entries as newline-delimited JSON over a single
+// long-lived request to the app's log stream endpoint, /a/{appID}/log/stream.
+// Write only buffers: a background goroutine writes entries to the open
+// connection. When the connection fails, the goroutine reconnects with the
+// client's retry backoff, and entries written meanwhile wait in a buffer of
+// AsyncQueueSize entries; once it is full, Write returns ErrLogQueueFull.
+// The entry being written when a connection fails is resent on up to
+// maxPendingAttempts connections, but entries the server had not processed
+// before the failure may be lost. Connection errors, entries that cannot be
+// marshaled and entries dropped after too many attempts are reported to the
+// ErrorHandler.
+type StreamLogger struct {
+	client  *Client
+	ctx     context.Context
+	url     string
+	entries chan LogPayload
+	closing chan struct{}
+	done    chan struct{}
+
+	// pending is an entry whose write failed, resent on the next connection;
+	// pendingAttempts counts the writes of it that failed so far
+	pending         *LogPayload
+	pendingAttempts int
+
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewStreamLogger opens a log stream. The stream runs until Close is called
+// or ctx is done; cancelling ctx drops entries that are still buffered.
+// Fields stored in ctx with ContextWithFields are added to every entry.
+func (c *Client) NewStreamLogger(ctx context.Context) *StreamLogger {
+	size := c.cfg.AsyncQueueSize
+	if size <= 0 {
+		size = defaultStreamBufferSize
+	}
+
+	s := &StreamLogger{
+		client:  c,
+		ctx:     ctx,
+		url:     c.logEndpoint("stream"),
+		entries: make(chan LogPayload, size),
+		closing: make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+
+	if c.cfg.DryRun {
+		close(s.done)
+	} else {
+		go s.run()
+	}
+
+	return s
+}
+
+// Write queues a log entry for the stream without blocking. Levels are
+// checked, default fields merged in and entries below the minimum level
+// dropped, like LogFields.
+func (s *StreamLogger) Write(p LogPayload) error {
+	if s.client.closed.Load() {
+		return ErrClientClosed
+	}
+
+	if !p.Level.Valid() {
+		return fmt.Errorf("%w: %q", ErrInvalidLogLevel, p.Level)
+	}
+
+	if !s.client.levelEnabled(p.Level) || !s.client.sampled(p.Level) {
+		return nil
+	}
+
+	payload, err := s.client.newPayload(s.ctx, p.Level, p.Message, p.Fields)
+	if err != nil {
+		return err
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if s.closed {
+		return ErrStreamClosed
+	}
+
+	if s.client.cfg.DryRun {
+		return s.client.writeDryRun(payload)
+	}
+
+	select {
+	case s.entries <- payload:
+		return nil
+	default:
+		return ErrLogQueueFull
+	}
+}
+
+// Close stops accepting entries, sends those still buffered if the stream is
+// connected, ends the request and waits for the server's response. It
+// returns an error saying how many entries were dropped, if any.
+func (s *StreamLogger) Close() error {
+	s.mu.Lock()
+	if !s.closed {
+		s.closed = true
+		close(s.closing)
+	}
+	s.mu.Unlock()
+
+	<-s.done
+
+	dropped := len(s.entries)
+	if s.pending != nil {
+		dropped++
+	}
+	if dropped > 0 {
+		return fmt.Errorf("log stream closed with %d unsent entries", dropped)
+	}
+
+	return nil
+}
+
+// run keeps the stream connected until it is closed or its context is done
+func (s *StreamLogger) run() {
+	defer close(s.done)
+
+	for attempt := 0; ; attempt++ {
+		final := false
+		if attempt > 0 {
+			timer := s.client.clock.NewTimer(s.client.backoff(attempt - 1))
+			select {
+			case <-timer.C():
+			case <-s.closing:
+				// Make one last attempt to send what is buffered
+				final = true
+			case <-s.ctx.Done():
+				timer.Stop()
+				return
+			}
+			timer.Stop()
+		}
+
+		wrote, stop := s.connect()
+		if stop || final {
+			return
+		}
+		if wrote {
+			attempt = 0
+		}
+	}
+}
+
+// connect runs one streaming request, going through the client's log rate
+// limiter and circuit breaker like other requests. It reports whether any
+// entry was written, and whether the stream should stop rather than reconnect.
+func (s *StreamLogger) connect() (wrote, stop bool) {
+	pr, pw := io.Pipe()
+
+	req, err := s.client.newRequest(s.ctx, "POST", s.url, nil)
+	if err != nil {
+		s.client.handleError(fmt.Errorf("failed to create log stream request: %w", err))
+		return false, false
+	}
+	req.Body = pr
+	req.Header.Set("Content-Type", "application/x-ndjson")
+
+	if err := s.client.logLimiter.wait(s.ctx); err != nil {
+		return false, true
+	}
+
+	ended := make(chan error, 1)
+	go func() {
+		resp, err := s.client.do(req, false)
+		if err == nil {
+			if !isSuccess(resp.StatusCode) {
+				err = newHTTPError(resp, nil)
+			} else {
+				_, _ = io.Copy(io.Discard, resp.Body)
+			}
+			resp.Body.Close()
+		}
+		pr.CloseWithError(errStreamEnded)
+		ended <- err
+	}()
+
+	// end reports why the request finished and whether it was unexpected
+	end := func(err error) {
+		if err != nil && s.ctx.Err() == nil {
+			s.client.handleError(fmt.Errorf("log stream disconnected: %w", err))
+		}
+	}
+
+	// write sends p, returning false when the connection failed. The request
+	// body is then closed and the request has finished.
+	write := func(p LogPayload) bool {
+		line, err := s.client.marshal(p)
+		if err != nil {
+			// Resending can't help, so drop the entry and keep the connection
+			s.client.handleError(fmt.Errorf("failed to marshal log stream entry: %w", err))
+			s.clearPending()
+			return true
+		}
+
+		if _, err := pw.Write(append(line, '\n')); err != nil {
+			s.retryLater(p, err)
+			pw.CloseWithError(err)
+			end(<-ended)
+			return false
+		}
+
+		s.clearPending()
+		wrote = true
+		return true
+	}
+
+	if s.pending != nil && !write(*s.pending) {
+		return wrote, false
+	}
+
+	for {
+		select {
+		case p := <-s.entries:
+			if !write(p) {
+				return wrote, false
+			}
+		case err := <-ended:
+			pw.Close()
+			end(err)
+			return wrote, false
+		case <-s.closing:
+			for len(s.entries) > 0 {
+				if !write(<-s.entries) {
+					return wrote, true
+				}
+			}
+			pw.Close()
+			end(<-ended)
+			return wrote, true
+		case <-s.ctx.Done():
+			pw.CloseWithError(s.ctx.Err())
+			<-ended
+			return wrote, true
+		}
+	}
+}
+
+// retryLater keeps p to resend on the next connection after its write failed
+// with err, unless it has now failed maxPendingAttempts times
+func (s *StreamLogger) retryLater(p LogPayload, err error) {
+	if s.pending == nil {
+		s.pendingAttempts = 0
+	}
+	s.pendingAttempts++
+
+	if s.pendingAttempts >= maxPendingAttempts {
+		s.client.handleError(fmt.Errorf("dropped log stream entry after %d failed attempts: %w", s.pendingAttempts, err))
+		s.clearPending()
+		return
+	}
+	s.pending = &p
+}
+
+func (s *StreamLogger) clearPending() {
+	s.pending = nil
+	s.pendingAttempts = 0
+}
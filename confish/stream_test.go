@@ -0,0 +1,139 @@
+package confish_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/bravilogy/confish-go/confish"
+	"github.com/bravilogy/confish-go/confish/confishtest"
+)
+
+func TestStreamLoggerDropsUnmarshalableEntry(t *testing.T) {
+	server := confishtest.NewServer()
+	defer server.Close()
+
+	var mu sync.Mutex
+	var errs []error
+	client, err := server.NewClient(confish.WithErrorHandler(func(err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		errs = append(errs, err)
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream := client.NewStreamLogger(context.Background())
+	if err := stream.Write(confish.LogPayload{Level: confish.LogLevelInfo, Message: "bad", Fields: map[string]interface{}{"f": func() {}}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := stream.Write(confish.LogPayload{Level: confish.LogLevelInfo, Message: "good"}); err != nil {
+		t.Fatal(err)
+	}
+
+	closed := make(chan error, 1)
+	go func() { closed <- stream.Close() }()
+	select {
+	case err := <-closed:
+		if err != nil {
+			t.Fatalf("Close() = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Close() did not return")
+	}
+
+	if logs := server.LoggedPayloads(); len(logs) != 1 || logs[0].Message != "good" {
+		t.Errorf("logged payloads = %+v, want only the good entry", logs)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), "marshal") {
+		t.Errorf("reported errors = %v, want one marshal error", errs)
+	}
+}
+
+func TestStreamLoggerRespectsCircuitBreaker(t *testing.T) {
+	var streams atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/log/stream") {
+			streams.Add(1)
+		}
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	var mu sync.Mutex
+	var errs []error
+	client, err := confish.NewClientWithOptions(server.URL, "app", "secret",
+		confish.WithRetries(0, 0),
+		confish.WithCircuitBreaker(confish.CircuitBreakerConfig{FailureThreshold: 1, Cooldown: time.Hour}),
+		confish.WithErrorHandler(func(err error) {
+			mu.Lock()
+			defer mu.Unlock()
+			errs = append(errs, err)
+		}),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result struct{}
+	if err := client.GetConfig("flags", &result); err == nil {
+		t.Fatal("GetConfig() succeeded against a failing server")
+	}
+
+	stream := client.NewStreamLogger(context.Background())
+	if err := stream.Write(confish.LogPayload{Level: confish.LogLevelInfo, Message: "hello"}); err != nil {
+		t.Fatal(err)
+	}
+	stream.Close()
+
+	if n := streams.Load(); n != 0 {
+		t.Errorf("stream requests with an open circuit = %d, want 0", n)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(errs) == 0 || !errors.Is(errs[0], confish.ErrCircuitOpen) {
+		t.Errorf("reported errors = %v, want ErrCircuitOpen", errs)
+	}
+}
+
+func TestStreamLoggerDeliversEveryEntry(t *testing.T) {
+	server := confishtest.NewServer()
+	defer server.Close()
+
+	client, err := server.NewClient()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	stream := client.NewStreamLogger(context.Background())
+	messages := []string{"one", "two", "three"}
+	for _, message := range messages {
+		if err := stream.Write(confish.LogPayload{Level: confish.LogLevelInfo, Message: message}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := stream.Close(); err != nil {
+		t.Fatalf("Close() = %v", err)
+	}
+
+	logs := server.LoggedPayloads()
+	if len(logs) != len(messages) {
+		t.Fatalf("logged payloads = %+v, want %d entries", logs, len(messages))
+	}
+	for i, message := range messages {
+		if logs[i].Message != message {
+			t.Errorf("entry %d = %q, want %q", i, logs[i].Message, message)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package confish
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// StreamConfig fetches a config and passes a decoder reading the response
+// body to handler, so large configs can be processed incrementally with
+// Token and Decode instead of being loaded into memory at once. The request
+// is authenticated and its status checked like GetConfig's; handler is only
+// called for a 2xx response. The decoder honours UseNumber and StrictDecode.
+// Configs are always fetched afresh: the ETag and disk caches are neither
+// used nor updated. The client's Timeout covers handler too.
+func (c *Client) StreamConfig(configID string, handler func(decoder *json.Decoder) error) error {
+	return c.StreamConfigContext(context.Background(), configID, handler)
+}
+
+// StreamConfigContext is like StreamConfig but aborts the request when ctx is cancelled or its deadline passes
+func (c *Client) StreamConfigContext(ctx context.Context, configID string, handler func(decoder *json.Decoder) error) (err error) {
+	if handler == nil {
+		return errors.New("handler cannot be nil")
+	}
+
+	var status int
+	ctx, endSpan := c.startSpan(ctx, SpanGetConfig, map[string]string{"confish.config_id": configID})
+	endObserve := c.observe(OpGetConfig)
+	defer func() {
+		endObserve(status, err)
+		endSpan(status, err)
+		if err != nil {
+			err = c.configError(configID, err)
+		}
+	}()
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	req, err := c.newConfigRequest(ctx, configID)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if err := c.configLimiter.wait(ctx); err != nil {
+		return fmt.Errorf("failed to fetch config: %w", err)
+	}
+
+	resp, err := c.do(req, true)
+	if err != nil {
+		return fmt.Errorf("failed to fetch config: %w", err)
+	}
+	defer resp.Body.Close()
+
+	status = resp.StatusCode
+	if resp.StatusCode == http.StatusTooManyRequests {
+		retryAfter, _ := parseRetryAfter(resp.Header.Get("Retry-After"), c.clock.Now())
+		return &RateLimitError{RetryAfter: retryAfter, HTTPError: newHTTPError(resp, nil)}
+	}
+	if !isSuccess(resp.StatusCode) {
+		return newHTTPError(resp, ErrConfigNotFound)
+	}
+
+	dec := json.NewDecoder(resp.Body)
+	if c.cfg.UseNumber {
+		dec.UseNumber()
+	}
+	if c.cfg.StrictDecode {
+		dec.DisallowUnknownFields()
+	}
+
+	return handler(dec)
+}
@@ -0,0 +1,111 @@
+package confish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// LogEntry is a log message stored by Confish, as returned by TailLogs
+type LogEntry struct {
+	Level     LogLevel               `json:"level"`
+	Message   string                 `json:"message"`
+	Timestamp time.Time              `json:"timestamp"`
+	Fields    map[string]interface{} `json:"fields,omitempty"`
+}
+
+// logEntriesResponse is the wrapped form of a log listing. The API may also
+// answer with a bare array.
+type logEntriesResponse struct {
+	Logs []LogEntry `json:"logs"`
+}
+
+// TailLogs returns the n most recent log entries of the app's default
+// stream, in the order the server returns them
+func (c *Client) TailLogs(n int) ([]LogEntry, error) {
+	return c.TailLogsContext(context.Background(), n)
+}
+
+// TailLogsContext is like TailLogs but aborts the request when ctx is cancelled or its deadline passes
+func (c *Client) TailLogsContext(ctx context.Context, n int) ([]LogEntry, error) {
+	return c.TailLogsRangeContext(ctx, n, time.Time{}, time.Time{})
+}
+
+// TailLogsRange is like TailLogs but only returns entries logged at or after
+// since and before until. A zero time leaves that end of the range open.
+func (c *Client) TailLogsRange(n int, since, until time.Time) ([]LogEntry, error) {
+	return c.TailLogsRangeContext(context.Background(), n, since, until)
+}
+
+// TailLogsRangeContext is like TailLogsRange but aborts the request when ctx is cancelled or its deadline passes
+func (c *Client) TailLogsRangeContext(ctx context.Context, n int, since, until time.Time) (entries []LogEntry, err error) {
+	if n <= 0 {
+		return nil, errors.New("n must be positive")
+	}
+
+	if !since.IsZero() && !until.IsZero() && !since.Before(until) {
+		return nil, errors.New("since must be before until")
+	}
+
+	var status int
+	ctx, endSpan := c.startSpan(ctx, SpanTailLogs, map[string]string{"confish.app_id": c.appID()})
+	endObserve := c.observe(OpTailLogs)
+	defer func() {
+		endObserve(status, err)
+		endSpan(status, err)
+	}()
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	query := url.Values{"limit": {strconv.Itoa(n)}}
+	if !since.IsZero() {
+		query.Set("since", since.UTC().Format(time.RFC3339Nano))
+	}
+	if !until.IsZero() {
+		query.Set("until", until.UTC().Format(time.RFC3339Nano))
+	}
+
+	req, err := c.newRequest(ctx, "GET", c.logEndpoint()+"?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+
+	if err := c.logLimiter.wait(ctx); err != nil {
+		return nil, fmt.Errorf("failed to fetch logs: %w", err)
+	}
+
+	resp, err := c.do(req, true)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch logs: %w", err)
+	}
+	defer resp.Body.Close()
+
+	status = resp.StatusCode
+	if !isSuccess(resp.StatusCode) {
+		return nil, fmt.Errorf("failed to fetch logs: %w", newHTTPError(resp, nil))
+	}
+
+	body, err := c.readBody(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if trimmed := bytes.TrimSpace(body); len(trimmed) > 0 && trimmed[0] == '[' {
+		err = json.Unmarshal(trimmed, &entries)
+	} else {
+		var wrapped logEntriesResponse
+		err = json.Unmarshal(body, &wrapped)
+		entries = wrapped.Logs
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal log entries: %w", err)
+	}
+
+	return entries, nil
+}
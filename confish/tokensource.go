@@ -0,0 +1,140 @@
+package confish
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// tokenRefreshMargin is how long before its expiry a token is refreshed
+const tokenRefreshMargin = 10 * time.Second
+
+// TokenSource returns a fresh bearer token and the time it expires. A zero
+// expiry means the token is used until the API rejects it. Its context is
+// not cancelled with the request that triggered the refresh, since other
+// requests may be waiting for the token, so it should bound its own duration.
+type TokenSource func(ctx context.Context) (token string, expiry time.Time, err error)
+
+// tokenSourceAuthorizer sends bearer tokens obtained from a TokenSource,
+// caching each one until shortly before it expires. Concurrent requests
+// needing a new token share a single call to the source.
+type tokenSourceAuthorizer struct {
+	source TokenSource
+	clock  clock
+
+	mu      sync.Mutex
+	token   string
+	expiry  time.Time
+	refresh *tokenRefresh
+}
+
+// tokenRefresh is a call to the token source in progress
+type tokenRefresh struct {
+	done  chan struct{}
+	token string
+	err   error
+}
+
+func newTokenSourceAuthorizer(source TokenSource, clk clock) *tokenSourceAuthorizer {
+	return &tokenSourceAuthorizer{source: source, clock: clk}
+}
+
+func (a *tokenSourceAuthorizer) Apply(req *http.Request) error {
+	token, err := a.get(req.Context())
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
+
+// get returns the cached token, fetching a new one when there is none or it
+// is about to expire
+func (a *tokenSourceAuthorizer) get(ctx context.Context) (string, error) {
+	a.mu.Lock()
+	if a.token != "" && (a.expiry.IsZero() || a.clock.Now().Before(a.expiry.Add(-tokenRefreshMargin))) {
+		token := a.token
+		a.mu.Unlock()
+		return token, nil
+	}
+
+	refresh := a.refresh
+	if refresh == nil {
+		refresh = &tokenRefresh{done: make(chan struct{})}
+		a.refresh = refresh
+		go a.fetch(context.WithoutCancel(ctx), refresh)
+	}
+	a.mu.Unlock()
+
+	select {
+	case <-refresh.done:
+		return refresh.token, refresh.err
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// fetch calls the token source for refresh and caches the result
+func (a *tokenSourceAuthorizer) fetch(ctx context.Context, refresh *tokenRefresh) {
+	token, expiry, err := a.source(ctx)
+	if err == nil && token == "" {
+		err = ErrNoToken
+	}
+	if err != nil {
+		err = fmt.Errorf("failed to get token: %w", err)
+	}
+
+	a.mu.Lock()
+	if err == nil {
+		a.token, a.expiry = token, expiry
+	}
+	a.refresh = nil
+	a.mu.Unlock()
+
+	refresh.token, refresh.err = token, err
+	close(refresh.done)
+}
+
+// invalidate drops the cached token if it is still the one sent in the
+// rejected request's Authorization header, so the next request fetches a
+// new one. Requests rejected together thus cause a single refresh.
+func (a *tokenSourceAuthorizer) invalidate(req *http.Request) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.token != "" && req.Header.Get("Authorization") == "Bearer "+a.token {
+		a.token = ""
+	}
+}
+
+// retryUnauthorized resends req once with a new token after the API answered
+// it with resp, a 401, when the client gets its tokens from a TokenSource.
+// Otherwise resp is returned as is.
+func (c *Client) retryUnauthorized(req *http.Request, resp *http.Response, idempotent bool) (*http.Response, error) {
+	auth, ok := c.authorizer.(*tokenSourceAuthorizer)
+	if !ok || !replayable(req) {
+		return resp, nil
+	}
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	auth.invalidate(req)
+
+	retry := req.Clone(req.Context())
+	if req.GetBody != nil {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		retry.Body = body
+	}
+	if err := auth.Apply(retry); err != nil {
+		return nil, &authorizeError{err: err}
+	}
+
+	return c.doWithRetry(retry, idempotent)
+}
@@ -0,0 +1,195 @@
+package confish
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// countingSource is a TokenSource handing out "token-1", "token-2", ... that
+// expire after ttl, or never when ttl is zero
+type countingSource struct {
+	clock   clock
+	ttl     time.Duration
+	calls   atomic.Int32
+	release chan struct{}
+}
+
+func (s *countingSource) source(ctx context.Context) (string, time.Time, error) {
+	n := s.calls.Add(1)
+	if s.release != nil {
+		<-s.release
+	}
+	var expiry time.Time
+	if s.ttl > 0 {
+		expiry = s.clock.Now().Add(s.ttl)
+	}
+	return fmt.Sprintf("token-%d", n), expiry, nil
+}
+
+// authServer records the Authorization header of each request and answers
+// 401 to the tokens in rejected
+func authServer(t *testing.T, rejected ...string) (*httptest.Server, func() []string) {
+	t.Helper()
+
+	var mu sync.Mutex
+	var seen []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth := r.Header.Get("Authorization")
+		mu.Lock()
+		seen = append(seen, auth)
+		mu.Unlock()
+
+		for _, token := range rejected {
+			if auth == "Bearer "+token {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+		}
+		w.Write([]byte(`{}`))
+	}))
+	t.Cleanup(server.Close)
+
+	return server, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), seen...)
+	}
+}
+
+func TestTokenSourceSharesRefresh(t *testing.T) {
+	server, seen := authServer(t)
+	src := &countingSource{release: make(chan struct{})}
+	client, err := NewClientWithOptions(server.URL, "app", "secret", WithTokenSource(src.source))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const callers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for i := 0; i < callers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var result map[string]interface{}
+			errs <- client.GetConfig("flags", &result)
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(src.release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		if err != nil {
+			t.Errorf("GetConfig() error = %v", err)
+		}
+	}
+	if n := src.calls.Load(); n != 1 {
+		t.Errorf("token source called %d times, want 1", n)
+	}
+	for _, auth := range seen() {
+		if auth != "Bearer token-1" {
+			t.Errorf("Authorization = %q, want %q", auth, "Bearer token-1")
+		}
+	}
+}
+
+func TestTokenSourceRetriesUnauthorizedOnce(t *testing.T) {
+	t.Run("new token accepted", func(t *testing.T) {
+		server, seen := authServer(t, "token-1")
+		src := &countingSource{}
+		client, err := NewClientWithOptions(server.URL, "app", "secret", WithTokenSource(src.source))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var result map[string]interface{}
+		if err := client.GetConfig("flags", &result); err != nil {
+			t.Fatalf("GetConfig() error = %v", err)
+		}
+		if got := seen(); len(got) != 2 || got[0] != "Bearer token-1" || got[1] != "Bearer token-2" {
+			t.Errorf("Authorization headers = %q, want token-1 then token-2", got)
+		}
+	})
+
+	t.Run("new token rejected", func(t *testing.T) {
+		server, seen := authServer(t, "token-1", "token-2", "token-3")
+		src := &countingSource{}
+		client, err := NewClientWithOptions(server.URL, "app", "secret", WithTokenSource(src.source))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var result map[string]interface{}
+		err = client.GetConfig("flags", &result)
+		var httpErr *HTTPError
+		if !errors.As(err, &httpErr) || httpErr.StatusCode != http.StatusUnauthorized {
+			t.Fatalf("GetConfig() error = %v, want a 401", err)
+		}
+		if got := seen(); len(got) != 2 {
+			t.Errorf("requests = %q, want the original and one retry", got)
+		}
+		if n := src.calls.Load(); n != 2 {
+			t.Errorf("token source called %d times, want 2", n)
+		}
+	})
+}
+
+func TestTokenSourceRefreshesBeforeExpiry(t *testing.T) {
+	server, seen := authServer(t)
+	clk := newFakeClock()
+	src := &countingSource{clock: clk, ttl: time.Minute}
+	client, err := NewClientWithOptions(server.URL, "app", "secret", WithTokenSource(src.source), withClock(clk))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	get := func() {
+		t.Helper()
+		var result map[string]interface{}
+		if err := client.GetConfig("flags", &result); err != nil {
+			t.Fatalf("GetConfig() error = %v", err)
+		}
+	}
+
+	get()
+	clk.Advance(time.Minute - tokenRefreshMargin - time.Second)
+	get()
+	clk.Advance(time.Second)
+	get()
+
+	want := []string{"Bearer token-1", "Bearer token-1", "Bearer token-2"}
+	if got := seen(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("Authorization headers = %q, want %q", got, want)
+	}
+}
+
+func TestTokenSourceFailureIsAuthorizeError(t *testing.T) {
+	server, _ := authServer(t, "token-1")
+	var calls atomic.Int32
+	client, err := NewClientWithOptions(server.URL, "app", "secret", WithTokenSource(func(context.Context) (string, time.Time, error) {
+		if calls.Add(1) == 1 {
+			return "token-1", time.Time{}, nil
+		}
+		return "", time.Time{}, errors.New("token endpoint down")
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result map[string]interface{}
+	err = client.GetConfig("flags", &result)
+	var authErr *authorizeError
+	if !errors.As(err, &authErr) {
+		t.Errorf("GetConfig() error = %v, want an *authorizeError", err)
+	}
+}
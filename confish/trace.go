@@ -0,0 +1,44 @@
+package confish
+
+import (
+	"context"
+	"net/http"
+)
+
+// Span names reported to the Tracer
+const (
+	SpanGetConfig    = "confish.GetConfig"
+	SpanUpdateConfig = "confish.UpdateConfig"
+	SpanListConfigs  = "confish.ListConfigs"
+	SpanConfigExists = "confish.ConfigExists"
+	SpanTailLogs     = "confish.TailLogs"
+	SpanLog          = "confish.Log"
+)
+
+// Tracer lets the client take part in distributed tracing without depending
+// on a tracing library. The otelconfish package provides an OpenTelemetry
+// implementation.
+type Tracer interface {
+	// Start begins a client span named name with the given attributes. It
+	// returns a context carrying the span and a function that ends it, given
+	// the HTTP status received (zero if none) and the resulting error.
+	Start(ctx context.Context, name string, attrs map[string]string) (context.Context, func(status int, err error))
+	// Inject writes the trace context carried by ctx into outgoing request
+	// headers, e.g. as a W3C traceparent header
+	Inject(ctx context.Context, header http.Header)
+}
+
+// startSpan starts a span with the configured Tracer, if any
+func (c *Client) startSpan(ctx context.Context, name string, attrs map[string]string) (context.Context, func(status int, err error)) {
+	if c.cfg.Tracer == nil {
+		return ctx, func(int, error) {}
+	}
+	return c.cfg.Tracer.Start(ctx, name, attrs)
+}
+
+// injectTrace propagates the trace context of req's context into its headers
+func (c *Client) injectTrace(req *http.Request) {
+	if c.cfg.Tracer != nil {
+		c.cfg.Tracer.Inject(req.Context(), req.Header)
+	}
+}
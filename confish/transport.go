@@ -0,0 +1,141 @@
+package confish
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+const (
+	// defaultMaxIdleConnsPerHost keeps enough idle connections to the API
+	// for concurrent callers; net/http keeps only two per host
+	defaultMaxIdleConnsPerHost = 16
+
+	defaultIdleConnTimeout = 90 * time.Second
+)
+
+// defaultHTTPClient is shared by every Client without its own HTTPClient or
+// pool settings so that connections are reused across calls and clients
+var defaultHTTPClient = &http.Client{Transport: newTransport(0, 0)}
+
+// newTransport clones http.DefaultTransport with the given pool settings,
+// using the package defaults for zero values
+func newTransport(maxIdleConnsPerHost int, idleConnTimeout time.Duration) *http.Transport {
+	if maxIdleConnsPerHost <= 0 {
+		maxIdleConnsPerHost = defaultMaxIdleConnsPerHost
+	}
+	if idleConnTimeout <= 0 {
+		idleConnTimeout = defaultIdleConnTimeout
+	}
+
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConnsPerHost = maxIdleConnsPerHost
+	t.IdleConnTimeout = idleConnTimeout
+	if t.MaxIdleConns < maxIdleConnsPerHost {
+		t.MaxIdleConns = maxIdleConnsPerHost
+	}
+
+	return t
+}
+
+// newHTTPClient returns the HTTP client a Client created from cfg uses
+func newHTTPClient(cfg *ConfishConfig) (*http.Client, error) {
+	tlsConfig, err := clientTLSConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	proxyURL, err := parseProxyURL(cfg.ProxyURL)
+	if err != nil {
+		return nil, err
+	}
+
+	// customize applies the settings that need a transport of our own
+	customize := func(t *http.Transport) {
+		if tlsConfig != nil {
+			t.TLSClientConfig = tlsConfig
+		}
+		if proxyURL != nil {
+			t.Proxy = http.ProxyURL(proxyURL)
+		}
+	}
+	customized := tlsConfig != nil || proxyURL != nil
+
+	if cfg.HTTPClient != nil {
+		if !customized {
+			return cfg.HTTPClient, nil
+		}
+
+		base := cfg.HTTPClient.Transport
+		if base == nil {
+			base = http.DefaultTransport
+		}
+		t, ok := base.(*http.Transport)
+		if !ok {
+			return nil, fmt.Errorf("config.TLSConfig and config.ProxyURL cannot be applied to HTTPClient transport of type %T", base)
+		}
+
+		t = t.Clone()
+		customize(t)
+		client := *cfg.HTTPClient
+		client.Transport = t
+		return &client, nil
+	}
+
+	if !customized && cfg.MaxIdleConnsPerHost <= 0 && cfg.IdleConnTimeout <= 0 {
+		return defaultHTTPClient, nil
+	}
+
+	t := newTransport(cfg.MaxIdleConnsPerHost, cfg.IdleConnTimeout)
+	customize(t)
+	return &http.Client{Transport: t}, nil
+}
+
+// parseProxyURL parses ConfishConfig.ProxyURL, returning nil when it is empty
+func parseProxyURL(raw string) (*url.URL, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	proxyURL, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("config.ProxyURL is not a valid URL: %w", err)
+	}
+
+	switch proxyURL.Scheme {
+	case "http", "https", "socks5", "socks5h":
+	default:
+		return nil, fmt.Errorf("config.ProxyURL must use the http, https or socks5 scheme, got %q", proxyURL.Redacted())
+	}
+
+	if proxyURL.Host == "" {
+		return nil, fmt.Errorf("config.ProxyURL must include a host, got %q", proxyURL.Redacted())
+	}
+
+	return proxyURL, nil
+}
+
+// clientTLSConfig combines TLSConfig with the client certificate files, or
+// returns nil when neither is set
+func clientTLSConfig(cfg *ConfishConfig) (*tls.Config, error) {
+	if cfg.TLSConfig == nil && cfg.ClientCertFile == "" && cfg.ClientKeyFile == "" {
+		return nil, nil
+	}
+
+	tlsConfig := &tls.Config{}
+	if cfg.TLSConfig != nil {
+		tlsConfig = cfg.TLSConfig.Clone()
+	}
+
+	if cfg.ClientCertFile != "" || cfg.ClientKeyFile != "" {
+		cert, err := tls.LoadX509KeyPair(cfg.ClientCertFile, cfg.ClientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = append(tlsConfig.Certificates, cert)
+	}
+
+	return tlsConfig, nil
+}
@@ -0,0 +1,153 @@
+package confish_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bravilogy/confish-go/confish"
+	"github.com/bravilogy/confish-go/confish/confishtest"
+)
+
+// writeClientCert creates a CA and a client certificate signed by it, writes
+// the certificate and key as PEM files and returns their paths with a pool
+// holding the CA
+func writeClientCert(t *testing.T) (certFile, keyFile string, pool *x509.CertPool) {
+	t.Helper()
+
+	caKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ca, err := x509.ParseCertificate(caDER)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	clientTemplate := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "test client"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+	clientDER, err := x509.CreateCertificate(rand.Reader, clientTemplate, ca, &clientKey.PublicKey, caKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(clientKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile = filepath.Join(dir, "client.crt")
+	keyFile = filepath.Join(dir, "client.key")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: clientDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	pool = x509.NewCertPool()
+	pool.AddCert(ca)
+	return certFile, keyFile, pool
+}
+
+func TestClientCertificate(t *testing.T) {
+	certFile, keyFile, clientCAs := writeClientCert(t)
+
+	server := confishtest.NewTLSServer(clientCAs)
+	defer server.Close()
+	if err := server.SetConfig("flags", map[string]bool{"beta": true}); err != nil {
+		t.Fatal(err)
+	}
+
+	t.Run("with certificate", func(t *testing.T) {
+		client, err := server.NewClient(confish.WithClientCertificate(certFile, keyFile))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var flags map[string]bool
+		if err := client.GetConfig("flags", &flags); err != nil {
+			t.Fatalf("GetConfig() error = %v", err)
+		}
+		if !flags["beta"] {
+			t.Errorf("flags = %v, want beta enabled", flags)
+		}
+	})
+
+	t.Run("without certificate", func(t *testing.T) {
+		client, err := server.NewClient()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		var flags map[string]bool
+		err = client.GetConfig("flags", &flags)
+		if !errors.Is(err, confish.ErrTLS) {
+			t.Fatalf("GetConfig() error = %v, want a TLS handshake error", err)
+		}
+	})
+}
+
+func TestProxyURL(t *testing.T) {
+	var gotURL, gotAuth string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotURL = r.URL.String()
+		gotAuth = r.Header.Get("Proxy-Authorization")
+		w.Write([]byte(`{"enabled":true}`))
+	}))
+	defer proxy.Close()
+
+	proxyURL := "http://user:pa%24s@" + strings.TrimPrefix(proxy.URL, "http://")
+	client, err := confish.NewClientWithOptions("http://config.example.test", "app", "secret", confish.WithProxy(proxyURL))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var result struct{ Enabled bool }
+	if err := client.GetConfig("flags", &result); err != nil || !result.Enabled {
+		t.Fatalf("GetConfig() = %v, %+v", err, result)
+	}
+
+	if want := "http://config.example.test/c/flags"; !strings.HasPrefix(gotURL, want) {
+		t.Errorf("proxied request URL = %q, want prefix %q", gotURL, want)
+	}
+	if want := "Basic " + base64.StdEncoding.EncodeToString([]byte("user:pa$s")); gotAuth != want {
+		t.Errorf("Proxy-Authorization = %q, want %q", gotAuth, want)
+	}
+}
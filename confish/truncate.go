@@ -0,0 +1,44 @@
+package confish
+
+import (
+	"errors"
+	"fmt"
+	"unicode/utf8"
+)
+
+// truncationMarker ends log messages shortened to fit MaxMessageBytes
+const truncationMarker = "…"
+
+// ErrMessageTooLarge is returned for log messages over MaxMessageBytes when
+// truncation is disabled
+var ErrMessageTooLarge = errors.New("confish: log message too large")
+
+// limitMessage applies MaxMessageBytes to message
+func (c *Client) limitMessage(message string) (string, error) {
+	max := c.cfg.MaxMessageBytes
+	if max <= 0 || len(message) <= max {
+		return message, nil
+	}
+
+	if c.cfg.DisableTruncation {
+		return "", fmt.Errorf("%w: %d bytes, limit is %d", ErrMessageTooLarge, len(message), max)
+	}
+
+	return truncateUTF8(message, max), nil
+}
+
+// truncateUTF8 shortens s to at most max bytes including the truncation
+// marker, without splitting a multi-byte rune
+func truncateUTF8(s string, max int) string {
+	marker := truncationMarker
+	if max < len(marker) {
+		marker = ""
+	}
+
+	cut := max - len(marker)
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+
+	return s[:cut] + marker
+}
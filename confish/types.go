@@ -0,0 +1,169 @@
+package confish
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Duration is a time.Duration that decodes from strings like "30s" or
+// "1h15m", as accepted by time.ParseDuration, or from a number of seconds.
+// It encodes as a string, e.g. "1m30s". JSON configs are decoded with
+// encoding/json, so this, like any field type with an UnmarshalJSON method,
+// is parsed by its own method.
+type Duration time.Duration
+
+// String formats the duration like time.Duration
+func (d Duration) String() string {
+	return time.Duration(d).String()
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (d *Duration) UnmarshalText(text []byte) error {
+	parsed, err := time.ParseDuration(strings.TrimSpace(string(text)))
+	if err != nil {
+		return fmt.Errorf("invalid duration: %w", err)
+	}
+
+	*d = Duration(parsed)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Like the standard library,
+// it leaves the duration unchanged for null.
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	if isJSONNull(data) {
+		return nil
+	}
+
+	if isJSONNumber(data) {
+		seconds, err := strconv.ParseFloat(string(data), 64)
+		if err != nil || math.Abs(seconds) > math.MaxInt64/float64(time.Second) {
+			return fmt.Errorf("invalid duration: %s", data)
+		}
+
+		*d = Duration(seconds * float64(time.Second))
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid duration: %s", data)
+	}
+
+	return d.UnmarshalText([]byte(s))
+}
+
+// Bytes is a size in bytes that decodes from strings like "512KB" or
+// "1.5GiB", or from a plain number of bytes. Decimal units (KB, MB, GB, TB)
+// are powers of 1000 and binary units (KiB, MiB, GiB, TiB) powers of 1024;
+// units are case-insensitive. It encodes as a string using the largest
+// binary unit that represents it exactly, e.g. "64MiB" or "1500B". Sizes
+// cannot be negative: they are rejected when decoding and encoding.
+type Bytes int64
+
+var byteUnits = map[string]float64{
+	"":    1,
+	"b":   1,
+	"kb":  1e3,
+	"mb":  1e6,
+	"gb":  1e9,
+	"tb":  1e12,
+	"kib": 1 << 10,
+	"mib": 1 << 20,
+	"gib": 1 << 30,
+	"tib": 1 << 40,
+}
+
+// String formats the size with the largest exact binary unit. Negative
+// sizes, which are invalid, are formatted as a plain number of bytes.
+func (b Bytes) String() string {
+	if b < 0 {
+		return strconv.FormatInt(int64(b), 10) + "B"
+	}
+
+	for _, unit := range []struct {
+		name string
+		size Bytes
+	}{{"TiB", 1 << 40}, {"GiB", 1 << 30}, {"MiB", 1 << 20}, {"KiB", 1 << 10}} {
+		if b != 0 && b%unit.size == 0 {
+			return strconv.FormatInt(int64(b/unit.size), 10) + unit.name
+		}
+	}
+
+	return strconv.FormatInt(int64(b), 10) + "B"
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (b Bytes) MarshalText() ([]byte, error) {
+	if b < 0 {
+		return nil, fmt.Errorf("invalid byte size %d: must not be negative", int64(b))
+	}
+	return []byte(b.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+func (b *Bytes) UnmarshalText(text []byte) error {
+	s := strings.TrimSpace(string(text))
+	split := strings.IndexFunc(s, func(r rune) bool {
+		return !unicode.IsDigit(r) && r != '.' && r != '-' && r != '+'
+	})
+	if split < 0 {
+		split = len(s)
+	}
+
+	number, unit := s[:split], strings.ToLower(strings.TrimSpace(s[split:]))
+	value, err := strconv.ParseFloat(number, 64)
+	multiplier, ok := byteUnits[unit]
+	if err != nil || !ok {
+		return fmt.Errorf("invalid byte size %q", s)
+	}
+
+	size := value * multiplier
+	if size < 0 || size >= math.MaxInt64 || size != math.Trunc(size) {
+		return fmt.Errorf("invalid byte size %q", s)
+	}
+
+	*b = Bytes(size)
+	return nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler. Like the standard library,
+// it leaves the size unchanged for null.
+func (b *Bytes) UnmarshalJSON(data []byte) error {
+	if isJSONNull(data) {
+		return nil
+	}
+
+	if isJSONNumber(data) {
+		return b.UnmarshalText(data)
+	}
+
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return fmt.Errorf("invalid byte size: %s", data)
+	}
+
+	return b.UnmarshalText([]byte(s))
+}
+
+// isJSONNumber reports whether data is a JSON number rather than a string
+func isJSONNumber(data []byte) bool {
+	data = bytes.TrimSpace(data)
+	return len(data) > 0 && (data[0] == '-' || (data[0] >= '0' && data[0] <= '9'))
+}
+
+// isJSONNull reports whether data is the JSON literal null
+func isJSONNull(data []byte) bool {
+	return string(bytes.TrimSpace(data)) == "null"
+}
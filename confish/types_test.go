@@ -0,0 +1,129 @@
+package confish_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/bravilogy/confish-go/confish"
+)
+
+func TestDurationRoundTrip(t *testing.T) {
+	for _, d := range []confish.Duration{
+		0,
+		confish.Duration(time.Second),
+		confish.Duration(90 * time.Second),
+		confish.Duration(1500 * time.Microsecond),
+		confish.Duration(-90 * time.Minute),
+	} {
+		data, err := json.Marshal(d)
+		if err != nil {
+			t.Fatalf("Marshal(%v) error = %v", d, err)
+		}
+
+		var got confish.Duration
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("Unmarshal(%s) error = %v", data, err)
+		}
+		if got != d {
+			t.Errorf("round trip of %v via %s = %v", d, data, got)
+		}
+	}
+}
+
+func TestDurationUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Duration
+	}{
+		{`"30s"`, 30 * time.Second},
+		{`" 1h15m "`, 75 * time.Minute},
+		{`90`, 90 * time.Second},
+		{`0.5`, 500 * time.Millisecond},
+	}
+
+	for _, tt := range tests {
+		var got confish.Duration
+		if err := json.Unmarshal([]byte(tt.input), &got); err != nil {
+			t.Errorf("Unmarshal(%s) error = %v", tt.input, err)
+			continue
+		}
+		if time.Duration(got) != tt.want {
+			t.Errorf("Unmarshal(%s) = %v, want %v", tt.input, got, tt.want)
+		}
+	}
+
+	for _, input := range []string{`"soon"`, `true`, `1e300`} {
+		var got confish.Duration
+		if err := json.Unmarshal([]byte(input), &got); err == nil {
+			t.Errorf("Unmarshal(%s) = %v, want error", input, got)
+		}
+	}
+}
+
+func TestBytesRoundTrip(t *testing.T) {
+	for _, b := range []confish.Bytes{0, 1, 1500, 1 << 10, 64 << 20, 3 << 30, 1<<40 + 1} {
+		data, err := json.Marshal(b)
+		if err != nil {
+			t.Fatalf("Marshal(%d) error = %v", int64(b), err)
+		}
+
+		var got confish.Bytes
+		if err := json.Unmarshal(data, &got); err != nil {
+			t.Fatalf("Unmarshal(%s) error = %v", data, err)
+		}
+		if got != b {
+			t.Errorf("round trip of %d via %s = %d", int64(b), data, int64(got))
+		}
+	}
+}
+
+func TestBytesRejectsNegative(t *testing.T) {
+	if data, err := json.Marshal(confish.Bytes(-1024)); err == nil {
+		t.Errorf("Marshal(-1024) = %s, want error", data)
+	}
+
+	for _, input := range []string{`-1`, `"-1KiB"`} {
+		var got confish.Bytes
+		if err := json.Unmarshal([]byte(input), &got); err == nil {
+			t.Errorf("Unmarshal(%s) = %d, want error", input, int64(got))
+		}
+	}
+}
+
+func TestBytesUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{`"512KB"`, 512e3},
+		{`"1.5GiB"`, 3 << 29},
+		{`"10 mb"`, 10e6},
+		{`2048`, 2048},
+	}
+
+	for _, tt := range tests {
+		var got confish.Bytes
+		if err := json.Unmarshal([]byte(tt.input), &got); err != nil {
+			t.Errorf("Unmarshal(%s) error = %v", tt.input, err)
+			continue
+		}
+		if int64(got) != tt.want {
+			t.Errorf("Unmarshal(%s) = %d, want %d", tt.input, int64(got), tt.want)
+		}
+	}
+}
+
+func TestNullLeavesValuesUnchanged(t *testing.T) {
+	cfg := struct {
+		Timeout confish.Duration `json:"timeout"`
+		MaxBody confish.Bytes    `json:"max_body"`
+	}{confish.Duration(time.Minute), 1 << 20}
+
+	if err := json.Unmarshal([]byte(`{"timeout":null,"max_body":null}`), &cfg); err != nil {
+		t.Fatalf("Unmarshal() error = %v", err)
+	}
+	if cfg.Timeout != confish.Duration(time.Minute) || cfg.MaxBody != 1<<20 {
+		t.Errorf("values after null = %v, %v, want unchanged", cfg.Timeout, cfg.MaxBody)
+	}
+}
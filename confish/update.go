@@ -0,0 +1,95 @@
+package confish
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// UpdateConfig replaces the values of a config with value, marshaled to
+// JSON. Errors match ErrForbidden when the app may not write the config,
+// ErrConfigNotFound when it doesn't exist and ErrConflict when the server
+// rejects the write as conflicting.
+func (c *Client) UpdateConfig(configID string, value interface{}) error {
+	return c.UpdateConfigContext(context.Background(), configID, value)
+}
+
+// UpdateConfigContext is like UpdateConfig but aborts the request when ctx is cancelled or its deadline passes
+func (c *Client) UpdateConfigContext(ctx context.Context, configID string, value interface{}) error {
+	return c.updateConfig(ctx, configID, value, "")
+}
+
+// UpdateConfigIfMatch is like UpdateConfig but only writes if the stored
+// config still has the given ETag, as returned in ConfigMeta by an earlier
+// read. If it changed since, the error matches ErrConflict.
+func (c *Client) UpdateConfigIfMatch(configID string, value interface{}, etag string) error {
+	return c.UpdateConfigIfMatchContext(context.Background(), configID, value, etag)
+}
+
+// UpdateConfigIfMatchContext is like UpdateConfigIfMatch but aborts the request when ctx is cancelled or its deadline passes
+func (c *Client) UpdateConfigIfMatchContext(ctx context.Context, configID string, value interface{}, etag string) error {
+	if etag == "" {
+		return errors.New("etag cannot be empty")
+	}
+
+	return c.updateConfig(ctx, configID, value, etag)
+}
+
+// updateConfig PUTs value to the config endpoint, conditionally on etag when
+// it is not empty
+func (c *Client) updateConfig(ctx context.Context, configID string, value interface{}, etag string) (err error) {
+	var status int
+	ctx, endSpan := c.startSpan(ctx, SpanUpdateConfig, map[string]string{"confish.config_id": configID})
+	endObserve := c.observe(OpUpdateConfig)
+	defer func() {
+		endObserve(status, err)
+		endSpan(status, err)
+		if err != nil {
+			err = c.configError(configID, err)
+		}
+	}()
+
+	ctx, cancel := c.withTimeout(ctx)
+	defer cancel()
+
+	body, err := json.Marshal(value)
+	if err != nil {
+		return fmt.Errorf("failed to marshal config: %w", err)
+	}
+
+	endpoint, err := c.configEndpoint(configID)
+	if err != nil {
+		return err
+	}
+
+	req, err := c.newRequest(ctx, "PUT", endpoint, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
+
+	if err := c.configLimiter.wait(ctx); err != nil {
+		return fmt.Errorf("failed to update config: %w", err)
+	}
+
+	// PUT is idempotent, and an If-Match write can't apply twice
+	resp, err := c.do(req, true)
+	if err != nil {
+		return fmt.Errorf("failed to update config: %w", err)
+	}
+	defer resp.Body.Close()
+
+	status = resp.StatusCode
+	if !isSuccess(resp.StatusCode) {
+		return fmt.Errorf("failed to update config: %w", newHTTPError(resp, ErrConfigNotFound))
+	}
+
+	// The cached copies no longer match what the server stores
+	c.etags.delete(configID)
+	c.cache.delete(configID)
+
+	return nil
+}
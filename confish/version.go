@@ -0,0 +1,18 @@
+package confish
+
+import "strings"
+
+// Version is the version of this library, sent in the User-Agent header
+const Version = "0.1.0"
+
+// defaultUserAgent is the User-Agent sent when no UserAgent is configured
+const defaultUserAgent = "confish-go/" + Version
+
+// userAgent returns the User-Agent header value for requests
+func (c *Client) userAgent() string {
+	if ua := strings.TrimSpace(c.cfg.UserAgent); ua != "" {
+		return ua + " " + defaultUserAgent
+	}
+
+	return defaultUserAgent
+}
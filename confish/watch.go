@@ -0,0 +1,94 @@
+package confish
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"math/rand/v2"
+	"time"
+)
+
+// WatchConfig polls configID every interval and calls onUpdate with the raw
+// config whenever it differs from the previous fetch, including the first
+// successful one. With PollJitter set, each interval is randomly shortened
+// or lengthened and the first poll is delayed so that replicas started
+// together don't poll in lockstep. It blocks until ctx is cancelled and then returns ctx.Err().
+// Fetch errors are ignored; use WatchConfigWithErrors to observe them.
+func (c *Client) WatchConfig(ctx context.Context, configID string, interval time.Duration, onUpdate func(json.RawMessage)) error {
+	return c.WatchConfigWithErrors(ctx, configID, interval, onUpdate, nil)
+}
+
+// WatchConfigWithErrors is like WatchConfig but calls onError, when non-nil,
+// with every failed fetch. Polling continues after an error.
+func (c *Client) WatchConfigWithErrors(ctx context.Context, configID string, interval time.Duration, onUpdate func(json.RawMessage), onError func(error)) error {
+	if interval <= 0 {
+		return errors.New("interval must be positive")
+	}
+
+	if onUpdate == nil {
+		return errors.New("onUpdate cannot be nil")
+	}
+
+	if err := c.sleepJitter(ctx, c.initialPollDelay(interval)); err != nil {
+		return err
+	}
+
+	// Polls are scheduled from the previous one's start, like a ticker
+	next := c.clock.Now()
+	var last []byte
+	for {
+		body, _, err := c.fetchConfig(ctx, configID)
+		switch {
+		case err != nil:
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if onError != nil {
+				onError(err)
+			}
+		case last == nil || !bytes.Equal(body, last):
+			last = body
+			onUpdate(json.RawMessage(body))
+		}
+
+		next = next.Add(c.pollInterval(interval))
+		if now := c.clock.Now(); next.Before(now) {
+			// The fetch overran the interval; poll again right away
+			next = now
+		}
+		if err := c.sleepJitter(ctx, next.Sub(c.clock.Now())); err != nil {
+			return err
+		}
+	}
+}
+
+// pollInterval returns interval adjusted by a random amount of up to
+// PollJitter times interval in either direction
+func (c *Client) pollInterval(interval time.Duration) time.Duration {
+	jitter := c.cfg.PollJitter
+	if jitter <= 0 {
+		return interval
+	}
+
+	return interval + time.Duration((rand.Float64()*2-1)*jitter*float64(interval))
+}
+
+// initialPollDelay returns a random delay of up to PollJitter times interval
+// before the first poll
+func (c *Client) initialPollDelay(interval time.Duration) time.Duration {
+	jitter := c.cfg.PollJitter
+	if jitter <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Float64() * jitter * float64(interval))
+}
+
+// sleepJitter waits for d, returning at once when d is not positive
+func (c *Client) sleepJitter(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return ctx.Err()
+	}
+	return sleepContext(ctx, c.clock, d)
+}
@@ -0,0 +1,335 @@
+package confish
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SignatureHeader is the request header carrying the webhook signature: the
+// hex-encoded HMAC-SHA256 of the raw request body keyed with AppSecret,
+// optionally prefixed with "sha256="
+const SignatureHeader = "X-Confish-Signature"
+
+// maxWebhookBodyBytes bounds how much of a webhook request body is read
+const maxWebhookBodyBytes = 1 << 20
+
+// ErrInvalidSignature is returned when a webhook signature is missing or does
+// not match the request body
+var ErrInvalidSignature = errors.New("confish: invalid webhook signature")
+
+// ErrWebhookTooLarge is returned for webhook requests whose body exceeds 1 MiB
+var ErrWebhookTooLarge = errors.New("confish: webhook body too large")
+
+// ErrPatchOnlyPayload is returned by WebhookHandler for payloads that carry a
+// patch but no values, since it has no current values to apply it to
+var ErrPatchOnlyPayload = errors.New("confish: webhook payload carries a patch but no values")
+
+// WebhookPayload represents a webhook payload type received from confish.
+// Unknown fields are ignored, so payloads from newer servers still parse.
+type WebhookPayload struct {
+	Event         string              `json:"event"`
+	Configuration ConfigurationObject `json:"configuration"`
+	// Timestamp is when the event happened, in RFC 3339 format. It is zero
+	// when the server doesn't send it or sends a value that isn't a valid
+	// timestamp.
+	Timestamp time.Time `json:"timestamp"`
+	// Actor identifies who made the change, when the server sends it
+	Actor string `json:"actor,omitempty"`
+}
+
+// UnmarshalJSON implements json.Unmarshaler. It parses Timestamp leniently,
+// so an empty or malformed timestamp doesn't reject the whole payload.
+func (p *WebhookPayload) UnmarshalJSON(data []byte) error {
+	type payload WebhookPayload
+	aux := struct {
+		*payload
+		Timestamp json.RawMessage `json:"timestamp"`
+	}{payload: (*payload)(p)}
+
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+
+	p.Timestamp = time.Time{}
+	var s string
+	if json.Unmarshal(aux.Timestamp, &s) == nil {
+		if t, err := time.Parse(time.RFC3339, s); err == nil {
+			p.Timestamp = t
+		}
+	}
+
+	return nil
+}
+
+// WebhookMeta describes a processed webhook event, e.g. for audit logs
+type WebhookMeta struct {
+	Event     string
+	Name      string
+	Timestamp time.Time
+	Actor     string
+}
+
+// ConfigurationObject represents a configuration object received from confish
+type ConfigurationObject struct {
+	Name   string          `json:"name"`
+	Values json.RawMessage `json:"values"`
+	// Patch, sent instead of Values for some updates, describes the change
+	// to the previous values; see ApplyWebhookPatch
+	Patch json.RawMessage `json:"patch,omitempty"`
+}
+
+// Webhook event types sent by confish
+const (
+	EventConfigurationCreated = "configuration.created"
+	EventConfigurationUpdated = "configuration.updated"
+	EventConfigurationDeleted = "configuration.deleted"
+)
+
+// UnsupportedEventError is returned for webhook events the client does not handle
+type UnsupportedEventError struct {
+	Event string
+}
+
+func (e *UnsupportedEventError) Error() string {
+	return fmt.Sprintf("unsupported event type: %s", e.Event)
+}
+
+// NewWebhookPayload builds a payload for event on the configuration name with
+// values marshaled to JSON, e.g. to test code that consumes webhooks. The
+// Timestamp is set to the current time.
+func NewWebhookPayload(event string, name string, values interface{}) (WebhookPayload, error) {
+	raw, err := json.Marshal(values)
+	if err != nil {
+		return WebhookPayload{}, fmt.Errorf("failed to marshal configuration values: %w", err)
+	}
+
+	return WebhookPayload{
+		Event:         event,
+		Configuration: ConfigurationObject{Name: name, Values: raw},
+		Timestamp:     time.Now().UTC(),
+	}, nil
+}
+
+// ProcessWebhookPayload processes a webhook payload and returns the configuration values.
+// Created and updated events unmarshal the values into result, then apply
+// default tags and validate it like a fetched config. When the payload only
+// carries a patch, it is applied to the value result already holds, as with
+// ApplyWebhookPatch. Deleted events
+// carry no values, so result is left untouched and nil is returned. Any other
+// event yields an *UnsupportedEventError.
+func (c *Client) ProcessWebhookPayload(payload WebhookPayload, result interface{}) error {
+	_, err := c.ProcessWebhookPayloadWithMeta(payload, result)
+	return err
+}
+
+// ProcessWebhookPayloadWithMeta is like ProcessWebhookPayload but also
+// returns the event's metadata. The metadata is filled in even when an
+// error is returned.
+func (c *Client) ProcessWebhookPayloadWithMeta(payload WebhookPayload, result interface{}) (WebhookMeta, error) {
+	meta := WebhookMeta{
+		Event:     payload.Event,
+		Name:      payload.Configuration.Name,
+		Timestamp: payload.Timestamp,
+		Actor:     payload.Actor,
+	}
+
+	switch payload.Event {
+	case EventConfigurationCreated, EventConfigurationUpdated:
+	case EventConfigurationDeleted:
+		return meta, nil
+	default:
+		return meta, &UnsupportedEventError{Event: payload.Event}
+	}
+
+	if err := checkTarget(result); err != nil {
+		return meta, err
+	}
+
+	values, err := webhookValues(payload, result)
+	if err != nil {
+		return meta, err
+	}
+
+	if err := c.decodeValues(values, result); err != nil {
+		return meta, err
+	}
+
+	return meta, nil
+}
+
+// isPatchOnly reports whether a payload describes its change with a patch
+// instead of full values
+func isPatchOnly(payload WebhookPayload) bool {
+	return !hasJSON(payload.Configuration.Values) && hasJSON(payload.Configuration.Patch)
+}
+
+// webhookValues returns the full values set by a created or updated webhook:
+// its Values, or its Patch applied to current marshaled as JSON
+func webhookValues(payload WebhookPayload, current interface{}) (json.RawMessage, error) {
+	if !isPatchOnly(payload) {
+		return payload.Configuration.Values, nil
+	}
+
+	raw, err := json.Marshal(current)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal current values: %w", err)
+	}
+
+	return ApplyWebhookPatch(raw, payload)
+}
+
+// VerifyWebhookSignature checks that signatureHeader, the value of the
+// SignatureHeader request header, is a valid signature of body. It returns
+// ErrInvalidSignature otherwise, and always when the client has no AppSecret.
+func (c *Client) VerifyWebhookSignature(body []byte, signatureHeader string) error {
+	signature, err := hex.DecodeString(strings.TrimPrefix(strings.TrimSpace(signatureHeader), "sha256="))
+	if err != nil || len(signature) == 0 {
+		return ErrInvalidSignature
+	}
+
+	_, appSecret := c.credentials()
+	if appSecret == "" {
+		return ErrInvalidSignature
+	}
+
+	if !hmac.Equal(signature, webhookMAC(appSecret, body)) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+// SignWebhook returns the SignatureHeader value Confish would send with body
+// when secret is the app secret, e.g. to test webhook handlers
+func SignWebhook(secret string, body []byte) string {
+	return hex.EncodeToString(webhookMAC(secret, body))
+}
+
+// webhookMAC returns the HMAC-SHA256 of body keyed with secret
+func webhookMAC(secret string, body []byte) []byte {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return mac.Sum(nil)
+}
+
+// ProcessWebhookRequest reads a webhook request, verifies its signature and
+// unmarshals the configuration values into result
+func (c *Client) ProcessWebhookRequest(r *http.Request, result interface{}) error {
+	payload, err := c.ValidateWebhook(r)
+	if err != nil {
+		return err
+	}
+
+	return c.ProcessWebhookPayload(payload, result)
+}
+
+// ProcessWebhookRequestWithMeta is like ProcessWebhookRequest but also
+// returns the event's metadata
+func (c *Client) ProcessWebhookRequestWithMeta(r *http.Request, result interface{}) (WebhookMeta, error) {
+	payload, err := c.ValidateWebhook(r)
+	if err != nil {
+		return WebhookMeta{}, err
+	}
+
+	return c.ProcessWebhookPayloadWithMeta(payload, result)
+}
+
+// ValidateWebhook reads a webhook request, verifies its signature and parses
+// the payload without unmarshaling the configuration values, e.g. to inspect
+// deliveries before acting on them. A bad signature yields
+// ErrInvalidSignature and a body over 1 MiB ErrWebhookTooLarge. The event
+// type is not checked; ProcessWebhookPayload does that when the payload is
+// applied.
+func (c *Client) ValidateWebhook(r *http.Request) (WebhookPayload, error) {
+	body, err := io.ReadAll(io.LimitReader(r.Body, maxWebhookBodyBytes+1))
+	if err != nil {
+		return WebhookPayload{}, fmt.Errorf("failed to read webhook body: %w", err)
+	}
+	if len(body) > maxWebhookBodyBytes {
+		return WebhookPayload{}, ErrWebhookTooLarge
+	}
+
+	if err := c.VerifyWebhookSignature(body, r.Header.Get(SignatureHeader)); err != nil {
+		return WebhookPayload{}, err
+	}
+
+	var payload WebhookPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return WebhookPayload{}, fmt.Errorf("failed to unmarshal webhook payload: %w", err)
+	}
+
+	return payload, nil
+}
+
+// WebhookHandler returns an http.Handler that receives Confish webhooks,
+// verifies their signature and calls onConfig with the created or updated
+// configuration. For deleted configurations values is nil. It responds 401
+// to badly signed requests, 413 to bodies over 1 MiB and 400 to malformed
+// payloads or unsupported events. It has no current values to apply a patch
+// to, so payloads that only carry one are rejected with a 400 and
+// ErrPatchOnlyPayload; use ConfigStore or ConfigReloader, which apply them
+// to the value they hold, or ValidateWebhook and ApplyWebhookPatch. When
+// WebhookPath is configured, requests to any other path get a 404.
+func (c *Client) WebhookHandler(onConfig func(name string, values json.RawMessage)) http.Handler {
+	return c.webhookHandler(func(payload WebhookPayload) error {
+		if isPatchOnly(payload) {
+			return ErrPatchOnlyPayload
+		}
+
+		var values json.RawMessage
+		if err := c.ProcessWebhookPayload(payload, &values); err != nil {
+			return err
+		}
+
+		if onConfig != nil {
+			onConfig(payload.Configuration.Name, values)
+		}
+		return nil
+	})
+}
+
+// webhookHandler serves verified webhook payloads to onPayload, responding
+// 400 with its error if it fails and 204 otherwise
+func (c *Client) webhookHandler(onPayload func(WebhookPayload) error) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if c.cfg.WebhookPath != "" && normalizePath(r.URL.Path) != c.cfg.WebhookPath {
+			http.NotFound(w, r)
+			return
+		}
+
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		payload, err := c.ValidateWebhook(r)
+		if errors.Is(err, ErrInvalidSignature) {
+			http.Error(w, "invalid signature", http.StatusUnauthorized)
+			return
+		}
+		if errors.Is(err, ErrWebhookTooLarge) {
+			http.Error(w, "payload too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			http.Error(w, "invalid payload", http.StatusBadRequest)
+			return
+		}
+
+		if err := onPayload(payload); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	})
+}
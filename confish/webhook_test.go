@@ -0,0 +1,300 @@
+package confish_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bravilogy/confish-go/confish"
+)
+
+// sendWebhook signs payload with secret and serves it to handler
+func sendWebhook(t *testing.T, handler http.Handler, secret string, payload confish.WebhookPayload) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body))
+	req.Header.Set(confish.SignatureHeader, confish.SignWebhook(secret, body))
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestWebhookDecodingHonorsClientOptions(t *testing.T) {
+	client, err := confish.NewClientWithOptions("https://api.example.test", "app", "secret", confish.WithUseNumber())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	payload, err := confish.NewWebhookPayload(confish.EventConfigurationUpdated, "limits", map[string]interface{}{"max": 9007199254740993})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := json.Number("9007199254740993")
+
+	t.Run("ProcessWebhookPayload", func(t *testing.T) {
+		var result map[string]interface{}
+		if err := client.ProcessWebhookPayload(payload, &result); err != nil {
+			t.Fatal(err)
+		}
+		if result["max"] != want {
+			t.Errorf("max = %#v, want %#v", result["max"], want)
+		}
+	})
+
+	t.Run("ConfigStore", func(t *testing.T) {
+		store := confish.NewConfigStore[map[string]interface{}](nil)
+		if rec := sendWebhook(t, store.WebhookHandler(client, "limits"), "secret", payload); rec.Code != http.StatusNoContent {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+		}
+		if got := store.Load()["max"]; got != want {
+			t.Errorf("max = %#v, want %#v", got, want)
+		}
+	})
+
+	t.Run("ConfigReloader", func(t *testing.T) {
+		reloader := confish.NewConfigReloader[map[string]interface{}]("limits")
+		if rec := sendWebhook(t, reloader.WebhookHandler(client), "secret", payload); rec.Code != http.StatusNoContent {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+		}
+		if got := reloader.Current()["max"]; got != want {
+			t.Errorf("max after webhook = %#v, want %#v", got, want)
+		}
+
+		if err := reloader.Apply(payload); err != nil {
+			t.Fatal(err)
+		}
+		if got := reloader.Current()["max"]; got != want {
+			t.Errorf("max after Apply = %#v, want %#v", got, want)
+		}
+	})
+}
+
+// limits is a config with a default and a Validate method
+type limits struct {
+	Max     int              `json:"max"`
+	Timeout confish.Duration `json:"timeout" confish:"default=5s"`
+}
+
+func (l *limits) Validate() error {
+	if l.Max <= 0 {
+		return errors.New("max must be positive")
+	}
+	return nil
+}
+
+func TestWebhookValuesAreDefaultedAndValidated(t *testing.T) {
+	client, err := confish.NewClientWithOptions("https://api.example.test", "app", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	valid, err := confish.NewWebhookPayload(confish.EventConfigurationUpdated, "limits", map[string]int{"max": 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	invalid, err := confish.NewWebhookPayload(confish.EventConfigurationUpdated, "limits", map[string]int{"max": 0})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := limits{Max: 3, Timeout: confish.Duration(5 * time.Second)}
+
+	t.Run("ProcessWebhookPayloadWithMeta", func(t *testing.T) {
+		var result limits
+		if _, err := client.ProcessWebhookPayloadWithMeta(valid, &result); err != nil || result != want {
+			t.Fatalf("ProcessWebhookPayloadWithMeta() = %v, %+v, want %+v", err, result, want)
+		}
+		if _, err := client.ProcessWebhookPayloadWithMeta(invalid, &result); err == nil {
+			t.Error("ProcessWebhookPayloadWithMeta() of invalid values succeeded")
+		}
+	})
+
+	t.Run("ConfigStore", func(t *testing.T) {
+		var reported []error
+		client, err := confish.NewClientWithOptions("https://api.example.test", "app", "secret",
+			confish.WithErrorHandler(func(err error) { reported = append(reported, err) }))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		store := confish.NewConfigStore(limits{})
+		handler := store.WebhookHandler(client, "limits")
+		sendWebhook(t, handler, "secret", valid)
+		if got := store.Load(); got != want {
+			t.Fatalf("Load() = %+v, want %+v", got, want)
+		}
+
+		sendWebhook(t, handler, "secret", invalid)
+		if got := store.Load(); got != want {
+			t.Errorf("Load() after invalid values = %+v, want %+v", got, want)
+		}
+		if len(reported) != 1 {
+			t.Errorf("reported errors = %v, want one", reported)
+		}
+	})
+
+	t.Run("ConfigReloader", func(t *testing.T) {
+		reloader := confish.NewConfigReloader[limits]("limits")
+		if err := reloader.Apply(valid); err != nil || reloader.Current() != want {
+			t.Fatalf("Apply() = %v, Current() = %+v, want %+v", err, reloader.Current(), want)
+		}
+		if err := reloader.Apply(invalid); err == nil {
+			t.Error("Apply() of invalid values succeeded")
+		}
+		if got := reloader.Current(); got != want {
+			t.Errorf("Current() after invalid values = %+v, want %+v", got, want)
+		}
+	})
+}
+
+func TestWebhookPatchOnlyPayload(t *testing.T) {
+	client, err := confish.NewClientWithOptions("https://api.example.test", "app", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	patch := confish.WebhookPayload{
+		Event: confish.EventConfigurationUpdated,
+		Configuration: confish.ConfigurationObject{
+			Name:  "limits",
+			Patch: json.RawMessage(`[{"op":"replace","path":"/max","value":7}]`),
+		},
+	}
+	initial := limits{Max: 3, Timeout: confish.Duration(time.Minute)}
+	want := limits{Max: 7, Timeout: confish.Duration(time.Minute)}
+
+	t.Run("ProcessWebhookPayload", func(t *testing.T) {
+		result := initial
+		if err := client.ProcessWebhookPayload(patch, &result); err != nil || result != want {
+			t.Errorf("ProcessWebhookPayload() = %v, %+v, want %+v", err, result, want)
+		}
+	})
+
+	t.Run("ConfigStore", func(t *testing.T) {
+		store := confish.NewConfigStore(initial)
+		if rec := sendWebhook(t, store.WebhookHandler(client, "limits"), "secret", patch); rec.Code != http.StatusNoContent {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+		}
+		if got := store.Load(); got != want {
+			t.Errorf("Load() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("ConfigReloader", func(t *testing.T) {
+		reloader := confish.NewConfigReloader[limits]("limits")
+		full, err := confish.NewWebhookPayload(confish.EventConfigurationCreated, "limits", initial)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := reloader.Apply(full); err != nil {
+			t.Fatal(err)
+		}
+
+		if rec := sendWebhook(t, reloader.WebhookHandler(client), "secret", patch); rec.Code != http.StatusNoContent {
+			t.Fatalf("status = %d: %s", rec.Code, rec.Body)
+		}
+		if got := reloader.Current(); got != want {
+			t.Errorf("Current() = %+v, want %+v", got, want)
+		}
+	})
+
+	t.Run("WebhookHandler", func(t *testing.T) {
+		called := false
+		handler := client.WebhookHandler(func(string, json.RawMessage) { called = true })
+		rec := sendWebhook(t, handler, "secret", patch)
+		if rec.Code != http.StatusBadRequest || !strings.Contains(rec.Body.String(), confish.ErrPatchOnlyPayload.Error()) {
+			t.Errorf("response = %d %q, want 400 with ErrPatchOnlyPayload", rec.Code, rec.Body)
+		}
+		if called {
+			t.Error("onConfig was called for a patch-only payload")
+		}
+	})
+}
+
+func TestWebhookPayloadTimestamp(t *testing.T) {
+	tests := []struct {
+		timestamp string
+		want      time.Time
+	}{
+		{`"2024-05-01T12:30:00Z"`, time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)},
+		{`""`, time.Time{}},
+		{`"yesterday"`, time.Time{}},
+		{`1714566600`, time.Time{}},
+		{`null`, time.Time{}},
+	}
+
+	for _, tt := range tests {
+		data := `{"event":"configuration.updated","configuration":{"name":"limits","values":{"max":1}},"timestamp":` + tt.timestamp + `,"actor":"ops"}`
+		var payload confish.WebhookPayload
+		if err := json.Unmarshal([]byte(data), &payload); err != nil {
+			t.Errorf("Unmarshal() with timestamp %s error = %v", tt.timestamp, err)
+			continue
+		}
+		if !payload.Timestamp.Equal(tt.want) {
+			t.Errorf("Timestamp from %s = %v, want %v", tt.timestamp, payload.Timestamp, tt.want)
+		}
+		if payload.Event != confish.EventConfigurationUpdated || payload.Configuration.Name != "limits" || payload.Actor != "ops" {
+			t.Errorf("payload with timestamp %s = %+v", tt.timestamp, payload)
+		}
+	}
+
+	payload, err := confish.NewWebhookPayload(confish.EventConfigurationUpdated, "limits", limits{Max: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(payload)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got confish.WebhookPayload
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Timestamp.Equal(payload.Timestamp) {
+		t.Errorf("round-tripped Timestamp = %v, want %v", got.Timestamp, payload.Timestamp)
+	}
+}
+
+func TestWebhookBodyLimit(t *testing.T) {
+	client, err := confish.NewClientWithOptions("https://api.example.test", "app", "secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := client.WebhookHandler(nil)
+
+	payload := func(padding int) confish.WebhookPayload {
+		p, err := confish.NewWebhookPayload(confish.EventConfigurationUpdated, "big", map[string]string{"blob": strings.Repeat("x", padding)})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return p
+	}
+
+	if rec := sendWebhook(t, handler, "secret", payload(1<<20-1024)); rec.Code != http.StatusNoContent {
+		t.Errorf("status for a body under 1 MiB = %d: %s", rec.Code, rec.Body)
+	}
+
+	big := payload(1 << 20)
+	if rec := sendWebhook(t, handler, "secret", big); rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("status for a body over 1 MiB = %d, want 413", rec.Code)
+	}
+
+	body, err := json.Marshal(big)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader(body))
+	req.Header.Set(confish.SignatureHeader, confish.SignWebhook("secret", body))
+	if _, err := client.ValidateWebhook(req); !errors.Is(err, confish.ErrWebhookTooLarge) {
+		t.Errorf("ValidateWebhook() error = %v, want ErrWebhookTooLarge", err)
+	}
+}